@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// newBatch builds the command sent by the list for the marked commands.
+// It returns to the list afterwards only when every marked command does.
+func newBatch(marked []Cmd, parallel bool) Cmd {
+	batch := Cmd{
+		Name:     fmt.Sprintf("%d marked commands", len(marked)),
+		Batch:    append([]Cmd{}, marked...),
+		Parallel: parallel,
+		Return:   true,
+	}
+	for _, cmd := range marked {
+		batch.Return = batch.Return && cmd.Return
+	}
+	return batch
+}
+
+// IsBatch reports whether the command carries commands marked in the list.
+func (c Cmd) IsBatch() bool {
+	return len(c.Batch) > 0
+}
+
+func (c Cmd) batchScript() string {
+	parts := make([]string, len(c.Batch))
+	for i, cmd := range c.Batch {
+		parts[i] = fmt.Sprintf("(%s)", cmd.Script())
+	}
+	if c.Parallel {
+		return strings.Join(parts, " & ") + " & wait"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// RunBatch runs the commands of batch one after another, or all at once when Parallel,
+// then prints the exit status of each. The exit code is the one of the first failed command.
+func RunBatch(batch Cmd) RunResult {
+	var results []summaryRow
+	if batch.Parallel {
+		results = runParallel(batch.Batch)
+	} else {
+		for _, cmd := range batch.Batch {
+			results = append(results, summaryRow{name: cmd.FullName(), result: RunCommand(cmd)})
+		}
+	}
+
+	var batchResult RunResult
+	for _, row := range results {
+		if batchResult.ExitCode == 0 {
+			batchResult.ExitCode = row.result.ExitCode
+		}
+		if batch.Parallel {
+			if row.result.Duration > batchResult.Duration {
+				batchResult.Duration = row.result.Duration
+			}
+		} else {
+			batchResult.Duration += row.result.Duration
+		}
+	}
+	printSummary("COMMAND", results)
+	printRunResult(batchResult)
+	return batchResult
+}
+
+var prefixColors = []color.Attribute{color.FgCyan, color.FgMagenta, color.FgYellow, color.FgBlue, color.FgGreen}
+
+// runParallel starts every command at once with its output prefixed by its name, they cannot read stdin.
+func runParallel(commands []Cmd) []summaryRow {
+	results := make([]summaryRow, len(commands))
+	width := 0
+	for _, cmd := range commands {
+		if len(cmd.FullName()) > width {
+			width = len(cmd.FullName())
+		}
+	}
+
+	defer ignoreInterrupts()()
+	var outputLock sync.Mutex
+	var wg sync.WaitGroup
+	for i, cmd := range commands {
+		results[i].name = cmd.FullName()
+		dir, env, err := commandContext(cmd)
+		if err != nil {
+			color.Red("Failed to prepare %s: %v", cmd.Name, err)
+			results[i].result = RunResult{ExitCode: -1}
+			continue
+		}
+		printCmdInfo(cmd, dir)
+
+		prefix := color.New(prefixColors[i%len(prefixColors)]).Sprintf("%-*s | ", width, cmd.FullName())
+		stdout := &prefixWriter{lock: &outputLock, out: os.Stdout, prefix: prefix}
+		stderr := &prefixWriter{lock: &outputLock, out: os.Stderr, prefix: prefix}
+		child := bashCommand(cmd.Script(), dir, env)
+		child.Stdout = stdout
+		child.Stderr = stderr
+
+		wg.Add(1)
+		go func(i int, cmd Cmd, dir string) {
+			defer wg.Done()
+			entry := newHistoryEntry(cmd, dir)
+			result := waitChild(child)
+			stdout.Flush()
+			stderr.Flush()
+			results[i].result = result
+			recordHistory(entry.withResult(result))
+		}(i, cmd, dir)
+	}
+	wg.Wait()
+	return results
+}
+
+// prefixWriter writes every complete line with prefix, lines of writers sharing lock do not interleave.
+type prefixWriter struct {
+	lock    *sync.Mutex
+	out     io.Writer
+	prefix  string
+	pending []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		end := bytes.IndexByte(w.pending, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+		w.writeLine(w.pending[:end+1])
+		w.pending = w.pending[end+1:]
+	}
+}
+
+// Flush writes the last line when the output does not end with a newline.
+func (w *prefixWriter) Flush() {
+	if len(w.pending) > 0 {
+		w.writeLine(append(w.pending, '\n'))
+		w.pending = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, _ = io.WriteString(w.out, w.prefix)
+	_, _ = w.out.Write(line)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestBatchScript(t *testing.T) {
+	marked := []Cmd{{Name: "a", Cmd: "make a"}, {Name: "b", Cmd: "make b", Return: true}}
+	var tests = []struct {
+		parallel bool
+		wat      string
+	}{
+		{false, "(make a); (make b)"},
+		{true, "(make a) & (make b) & wait"},
+	}
+	for _, tt := range tests {
+		batch := newBatch(marked, tt.parallel)
+		msg := fmt.Sprintf("parallel: %v", tt.parallel)
+		Equals(t, msg, tt.wat, batch.Script())
+		Equals(t, msg, false, batch.Return)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var lock sync.Mutex
+	a := &prefixWriter{lock: &lock, out: &out, prefix: "a | "}
+	b := &prefixWriter{lock: &lock, out: &out, prefix: "b | "}
+
+	_, _ = a.Write([]byte("one\ntw"))
+	_, _ = b.Write([]byte("three\n"))
+	_, _ = a.Write([]byte("o\nfour"))
+	a.Flush()
+	b.Flush()
+	Equals(t, "output", "a | one\nb | three\na | two\na | four\n", out.String())
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+)
+
+const cliUsage = `Usage:
+  c                   open the command list
+  c <alias> [k=v...]  run the command with this alias, k=v fills its placeholders
+  c list [--json]     print the commands
+  c --filter <query>  print the commands matching query, like the search mode
+  c --print           print the selected command instead of running it
+  c init <shell>      print the key binding widget of bash, zsh or fish
+  c config path       print the config file in use
+  c validate [file..] check the config and project files, or the given files
+
+The config file is --config, else $C_CONFIG, else the first existing of
+$XDG_CONFIG_HOME/c/config.yaml, ~/.c.conf and .c.conf next to the binary.
+
+`
+
+type cliOptions struct {
+	filter    string
+	filterSet bool
+	print     bool
+	config    string
+	command   string
+	args      []string
+}
+
+// interactive reports whether the list should be opened.
+func (o cliOptions) interactive() bool {
+	return o.command == "" && !o.filterSet
+}
+
+func parseArgs(args []string) (cliOptions, error) {
+	var opts cliOptions
+	fs := flag.NewFlagSet("c", flag.ContinueOnError)
+	fs.StringVar(&opts.filter, "filter", "", "print the commands matching `query`")
+	fs.BoolVar(&opts.print, "print", false, "print the selected command instead of running it")
+	fs.StringVar(&opts.config, "config", "", "use the config `file`")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), cliUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "filter" {
+			opts.filterSet = true
+		}
+	})
+	if rest := fs.Args(); len(rest) > 0 {
+		opts.command = rest[0]
+		opts.args = rest[1:]
+	}
+	return opts, nil
+}
+
+// subcommands are the commands of c itself, they are looked up before the aliases.
+var subcommands = []string{"init", "config", "validate", "list"}
+
+func isSubcommand(name string) bool {
+	for _, subcommand := range subcommands {
+		if name == subcommand {
+			return true
+		}
+	}
+	return false
+}
+
+// runCLI handles the non-interactive invocations and returns the exit code.
+func runCLI(opts cliOptions) int {
+	switch opts.command {
+	case "init":
+		return printShellWidget(opts.args)
+	case "config":
+		return printConfigInfo(opts.args)
+	case "validate":
+		return validate(opts.args)
+	}
+
+	commands := flattenCommands(annotatePaths(LoadConfig().Commands, nil))
+	if opts.filterSet {
+		printCommands(os.Stdout, searchCommands(commands, opts.filter, matchers[0]))
+		return 0
+	}
+
+	switch opts.command {
+	case "list":
+		fs := flag.NewFlagSet("c list", flag.ContinueOnError)
+		asJSON := fs.Bool("json", false, "print the commands as JSON")
+		if err := fs.Parse(opts.args); err != nil {
+			return 2
+		}
+		if *asJSON {
+			return printCommandsJSON(os.Stdout, commands)
+		}
+		printCommands(os.Stdout, commands)
+		return 0
+	default:
+		return runAlias(commands, opts.command, opts.args, opts.print)
+	}
+}
+
+// validate checks the given files, by default the project files of the working directory and the config file,
+// in the order their commands are merged.
+func validate(files []string) int {
+	if len(files) == 0 {
+		if cwd, err := os.Getwd(); err == nil {
+			files = append(files, projectFiles(cwd)...)
+		}
+		files = append(files, configFile)
+	}
+	if !validateFiles(files) {
+		return 1
+	}
+	color.Green("%s: ok", strings.Join(files, ", "))
+	return 0
+}
+
+func printConfigInfo(args []string) int {
+	if len(args) != 1 || args[0] != "path" {
+		color.Red("Usage: c config path")
+		return 2
+	}
+	fmt.Println(configFile)
+	fmt.Fprintf(os.Stderr, "(%s)\n", configSource)
+	return 0
+}
+
+func printShellWidget(args []string) int {
+	if len(args) != 1 {
+		color.Red("Usage: c init <bash|zsh|fish>")
+		return 2
+	}
+	script, err := shellWidget(args[0])
+	if err != nil {
+		color.Red("%v", err)
+		return 2
+	}
+	fmt.Print(script)
+	return 0
+}
+
+// runAlias executes the command with the given alias, the arguments fill its placeholders as name=value.
+func runAlias(commands []Cmd, alias string, args []string, printOnly bool) int {
+	cmd, ok := findByAlias(commands, alias)
+	if !ok {
+		color.Red("Unknown command or alias: %s", alias)
+		return 1
+	}
+
+	values, err := placeholderValues(cmd, args)
+	if err != nil {
+		color.Red("%v", err)
+		return 2
+	}
+	cmd = resolveCommand(cmd, values)
+	recordUsage(cmd)
+	if printOnly {
+		PrintCommand(cmd)
+	}
+	ExecCommand(cmd)
+	return 0
+}
+
+func findByAlias(commands []Cmd, alias string) (Cmd, bool) {
+	for _, cmd := range commands {
+		if cmd.Alias != "" && cmd.Alias == alias {
+			return cmd, true
+		}
+	}
+	return Cmd{}, false
+}
+
+// placeholderValues parses name=value arguments, every placeholder without a default must be given.
+func placeholderValues(cmd Cmd, args []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, arg := range args {
+		i := strings.Index(arg, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid placeholder value %q, expected name=value", arg)
+		}
+		values[arg[:i]] = arg[i+1:]
+	}
+
+	var missing []string
+	for _, placeholder := range parsePlaceholders(cmd.Script()) {
+		if _, ok := values[placeholder.Name]; !ok && placeholder.Default == "" {
+			missing = append(missing, placeholder.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing placeholder values for %s: %s", cmd.Name, strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+func printCommands(w io.Writer, commands []Cmd) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", cmd.FullName(), cmd.Alias, cmd.Script())
+	}
+	_ = tw.Flush()
+}
+
+// commandInfo is the JSON form of a command printed by c list --json.
+type commandInfo struct {
+	Name  string   `json:"name"`
+	Path  []string `json:"path,omitempty"`
+	Alias string   `json:"alias,omitempty"`
+	Cmd   string   `json:"cmd"`
+	Dir   string   `json:"dir,omitempty"`
+}
+
+func printCommandsJSON(w io.Writer, commands []Cmd) int {
+	infos := make([]commandInfo, 0, len(commands))
+	for _, cmd := range commands {
+		infos = append(infos, commandInfo{Name: cmd.Name, Path: cmd.Path, Alias: cmd.Alias, Cmd: cmd.Script(), Dir: cmd.Dir})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(infos); err != nil {
+		color.Red("Failed to print commands: %v", err)
+		return 1
+	}
+	return 0
+}
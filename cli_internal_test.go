@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	var tests = []struct {
+		args []string
+		wat  cliOptions
+	}{
+		{nil, cliOptions{}},
+		{[]string{"list", "--json"}, cliOptions{command: "list", args: []string{"--json"}}},
+		{[]string{"--filter", "db"}, cliOptions{filter: "db", filterSet: true}},
+		{[]string{"--filter="}, cliOptions{filterSet: true}},
+		{[]string{"deploy", "host=10.0.0.1"}, cliOptions{command: "deploy", args: []string{"host=10.0.0.1"}}},
+	}
+	for _, tt := range tests {
+		got, err := parseArgs(tt.args)
+		msg := fmt.Sprintf("args: %v", tt.args)
+		Equals(t, msg, nil, err)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestPlaceholderValues(t *testing.T) {
+	cmd := Cmd{Name: "deploy", Cmd: "deploy {{host}} {{branch:main}}"}
+	var tests = []struct {
+		args    []string
+		wat     map[string]string
+		wantErr bool
+	}{
+		{[]string{"host=a=b"}, map[string]string{"host": "a=b"}, false},
+		{[]string{"host=x", "branch=dev"}, map[string]string{"host": "x", "branch": "dev"}, false},
+		{[]string{"branch=dev"}, nil, true},
+		{[]string{"host"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := placeholderValues(cmd, tt.args)
+		msg := fmt.Sprintf("args: %v", tt.args)
+		Equals(t, msg, tt.wantErr, err != nil)
+		Equals(t, msg, tt.wat, got)
+	}
+}
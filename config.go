@@ -4,41 +4,185 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-var configFile string
+// configFile is the config file in use and configSource tells how it was found, see resolveConfigFile.
+var configFile, configSource string
 
-func init() {
-	configFile = filepath.Dir(os.Args[0]) + "/.c.conf"
+const configEnv = "C_CONFIG"
+
+type configLocation struct {
+	path   string
+	source string
+}
+
+// resolveConfigFile picks the config file from the --config flag, then $C_CONFIG, then the first existing
+// of $XDG_CONFIG_HOME/c/config.yaml, ~/.c.conf and .c.conf next to the binary.
+// When none exists, the XDG one is returned so that the bootstrap commands are written there.
+// A relative path is made absolute, the dirs of the commands are relative to the config file.
+func resolveConfigFile(flagPath string) (string, string) {
+	if flagPath != "" {
+		return absPath(flagPath), "--config flag"
+	}
+	if envPath := os.Getenv(configEnv); envPath != "" {
+		return absPath(envPath), "$" + configEnv
+	}
+	locations := defaultConfigLocations()
+	for _, location := range locations {
+		if _, err := os.Stat(location.path); err == nil {
+			return location.path, location.source
+		}
+	}
+	return locations[0].path, locations[0].source + ", not created yet"
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+func defaultConfigLocations() []configLocation {
+	var locations []configLocation
+	home, homeErr := os.UserHomeDir()
+	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
+		locations = append(locations, configLocation{filepath.Join(xdgHome, "c", "config.yaml"), "$XDG_CONFIG_HOME"})
+	} else if homeErr == nil {
+		locations = append(locations, configLocation{filepath.Join(home, ".config", "c", "config.yaml"), "$XDG_CONFIG_HOME"})
+	}
+	if homeErr == nil {
+		locations = append(locations, configLocation{filepath.Join(home, ".c.conf"), "home directory"})
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		executable = os.Args[0]
+	}
+	locations = append(locations, configLocation{filepath.Join(filepath.Dir(executable), ".c.conf"), "binary directory"})
+	return locations
+}
+
+// Config is the content of the config file. A file holding only the list of commands is also accepted.
+type Config struct {
+	Return   bool  `yaml:"return"`
+	Commands []Cmd `yaml:"commands"`
+	// Keymap binds keys to actions by mode, see newKeymap.
+	Keymap map[string]map[string]string `yaml:"keymap"`
+	Theme  ThemeConfig                  `yaml:"theme"`
 }
 
 type Cmd struct {
-	Cmd   string `yaml:"cmd"`
-	Name  string `yaml:"name"`
-	Alias string `yaml:"alias"`
+	Cmd         string   `yaml:"cmd"`
+	Name        string   `yaml:"name"`
+	Alias       string   `yaml:"alias"`
+	Description string   `yaml:"description"`
+	Tags        []string `yaml:"tags"`
+	Children    []Cmd    `yaml:"children"`
+	Return      bool     `yaml:"return"`
+	// Dir is the working directory of the command, empty to inherit the one of c.
+	Dir     string            `yaml:"dir"`
+	Env     map[string]string `yaml:"env"`
+	EnvFile string            `yaml:"env_file"`
+	// Steps make the command a pipeline run in place of cmd, see runSteps.
+	Steps []Step `yaml:"steps"`
+	// Rsync tunes the uploads and downloads of an ssh command, see buildRsyncCmd.
+	Rsync RsyncOptions `yaml:"rsync"`
+
+	// Path holds the names of the groups containing the command, it is filled by annotatePaths.
+	Path []string `yaml:"-"`
+	// Source is the project file of the command, empty for the global config, see loadProjectCommands.
+	Source string `yaml:"-"`
+	// Batch holds the commands marked in the list, they are run by RunBatch, all at once when Parallel.
+	Batch    []Cmd `yaml:"-"`
+	Parallel bool  `yaml:"-"`
 }
 
-func LoadCommands() []Cmd {
-	var commands []Cmd
+// IsGroup reports whether the entry is a group of commands rather than a runnable command.
+func (c Cmd) IsGroup() bool {
+	return len(c.Children) > 0
+}
+
+// FullName is the name prefixed with the path of groups containing the command.
+func (c Cmd) FullName() string {
+	return strings.Join(append(append([]string{}, c.Path...), c.Name), "/")
+}
+
+// annotatePaths returns a copy of the tree where every entry knows the path of groups containing it.
+func annotatePaths(commands []Cmd, path []string) []Cmd {
+	annotated := make([]Cmd, len(commands))
+	for i, command := range commands {
+		command.Path = path
+		if command.IsGroup() {
+			groupPath := append(append([]string{}, path...), command.Name)
+			command.Children = annotatePaths(command.Children, groupPath)
+		}
+		annotated[i] = command
+	}
+	return annotated
+}
+
+// flattenCommands collects every runnable command of the tree.
+func flattenCommands(commands []Cmd) []Cmd {
+	var flattened []Cmd
+	for _, command := range commands {
+		if command.IsGroup() {
+			flattened = append(flattened, flattenCommands(command.Children)...)
+			continue
+		}
+		flattened = append(flattened, command)
+	}
+	return flattened
+}
+
+// LoadConfig loads the global config, with the commands of the project files of the working directory first.
+func LoadConfig() Config {
+	var projectCommands []Cmd
+	if cwd, err := os.Getwd(); err == nil {
+		projectCommands = loadProjectCommands(cwd)
+	}
+
+	var config Config
 	var data []byte
 	var err error
 	if data, err = ioutil.ReadFile(filepath.Clean(configFile)); err != nil {
 		color.Green("Init bootstrap demo commands, please modify it: %s", configFile)
-		return initBootstrapCommands()
+		return Config{Commands: append(projectCommands, initBootstrapCommands()...)}
 	}
-	if err = yaml.Unmarshal(data, &commands); err != nil {
-		color.Red("Failed to parse %s", configFile)
+	if diagnostics := validateConfig(configFile, data); hasErrors(diagnostics) {
+		printDiagnostics(diagnostics)
+		color.Red("Failed to parse %s, check it with: c validate", configFile)
+		os.Exit(1)
+	}
+	if config, err = parseConfig(data); err != nil {
+		color.Red("Failed to parse %s: %v", configFile, err)
 		os.Exit(1)
 	}
 
-	if len(commands) == 0 {
+	config.Commands = append(projectCommands, config.Commands...)
+	if len(config.Commands) == 0 {
 		color.Red("%s is empty, please fill in your configuration.", configFile)
 		os.Exit(1)
 	}
-	return commands
+	return config
+}
+
+// parseConfig accepts either a plain list of commands or a mapping of global options with a commands list.
+func parseConfig(data []byte) (Config, error) {
+	var config Config
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return config, err
+	}
+	if _, isList := raw.([]interface{}); isList {
+		err := yaml.Unmarshal(data, &config.Commands)
+		return config, err
+	}
+	err := yaml.Unmarshal(data, &config)
+	return config, err
 }
 
 func initBootstrapCommands() []Cmd {
@@ -50,6 +194,10 @@ func initBootstrapCommands() []Cmd {
 		color.Red("Init bootstrap commands failed")
 		os.Exit(1)
 	}
+	if err := os.MkdirAll(filepath.Dir(configFile), 0700); err != nil {
+		color.Red("Init bootstrap commands failed: %v", err)
+		os.Exit(1)
+	}
 	if err := ioutil.WriteFile(configFile, data, 0600); err != nil {
 		color.Red("Init bootstrap commands failed")
 		os.Exit(1)
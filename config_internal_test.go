@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	var tests = []struct {
+		data string
+		wat  Config
+	}{
+		{"- name: date\n  cmd: date", Config{Commands: []Cmd{{Name: "date", Cmd: "date"}}}},
+		{"return: true\ncommands:\n- name: date\n  cmd: date\n  return: true", Config{Return: true, Commands: []Cmd{{Name: "date", Cmd: "date", Return: true}}}},
+	}
+	for _, tt := range tests {
+		got, err := parseConfig([]byte(tt.data))
+		msg := fmt.Sprintf("data: %s", tt.data)
+		Equals(t, msg, nil, err)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestResolveConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-config")
+	Equals(t, "create temp dir", nil, err)
+	defer os.RemoveAll(dir)
+
+	defaultEnv := map[string]string{}
+	for _, name := range []string{configEnv, "XDG_CONFIG_HOME", "HOME"} {
+		defaultEnv[name] = os.Getenv(name)
+	}
+	defer func() {
+		for name, value := range defaultEnv {
+			_ = os.Setenv(name, value)
+		}
+	}()
+	_ = os.Setenv(configEnv, "")
+	_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	_ = os.Setenv("HOME", dir)
+	xdgFile := filepath.Join(dir, "xdg", "c", "config.yaml")
+	homeFile := filepath.Join(dir, ".c.conf")
+
+	got, _ := resolveConfigFile("")
+	Equals(t, "nothing exists", xdgFile, got)
+
+	Equals(t, "create home file", nil, ioutil.WriteFile(homeFile, nil, 0600))
+	got, _ = resolveConfigFile("")
+	Equals(t, "home file exists", homeFile, got)
+
+	Equals(t, "create xdg dir", nil, os.MkdirAll(filepath.Dir(xdgFile), 0700))
+	Equals(t, "create xdg file", nil, ioutil.WriteFile(xdgFile, nil, 0600))
+	got, _ = resolveConfigFile("")
+	Equals(t, "xdg file exists", xdgFile, got)
+
+	_ = os.Setenv(configEnv, "/env/c.yaml")
+	got, _ = resolveConfigFile("")
+	Equals(t, "env is set", "/env/c.yaml", got)
+	got, _ = resolveConfigFile("/flag/c.yaml")
+	Equals(t, "flag is set", "/flag/c.yaml", got)
+
+	cwd, err := os.Getwd()
+	Equals(t, "get working dir", nil, err)
+	_ = os.Setenv(configEnv, "sub/c.yaml")
+	got, _ = resolveConfigFile("")
+	Equals(t, "relative env", filepath.Join(cwd, "sub", "c.yaml"), got)
+	got, _ = resolveConfigFile("../c.yaml")
+	Equals(t, "relative flag", filepath.Join(filepath.Dir(cwd), "c.yaml"), got)
+}
+
+func TestAnnotatePaths(t *testing.T) {
+	commands := []Cmd{
+		{Name: "date", Cmd: "date"},
+		{Name: "web", Children: []Cmd{
+			{Name: "build", Cmd: "make"},
+			{Name: "deploy", Children: []Cmd{{Name: "prod", Cmd: "make prod"}}},
+		}},
+	}
+	wat := []Cmd{
+		{Name: "date", Cmd: "date"},
+		{Name: "web", Children: []Cmd{
+			{Name: "build", Cmd: "make", Path: []string{"web"}},
+			{Name: "deploy", Path: []string{"web"}, Children: []Cmd{{Name: "prod", Cmd: "make prod", Path: []string{"web", "deploy"}}}},
+		}},
+	}
+	Equals(t, "annotate paths", wat, annotatePaths(commands, nil))
+	Equals(t, "commands are not modified", []string(nil), commands[1].Children[0].Path)
+}
+
+func TestFlattenCommands(t *testing.T) {
+	var tests = []struct {
+		commands []Cmd
+		wat      []Cmd
+	}{
+		{nil, nil},
+		{[]Cmd{{Name: "a", Cmd: "a"}, {Name: "b", Cmd: "b"}}, []Cmd{{Name: "a", Cmd: "a"}, {Name: "b", Cmd: "b"}}},
+		{[]Cmd{{Name: "a", Cmd: "a"}, {Name: "g", Children: []Cmd{{Name: "b", Cmd: "b"}, {Name: "h", Children: []Cmd{{Name: "c", Cmd: "c"}}}}}, {Name: "d", Cmd: "d"}},
+			[]Cmd{{Name: "a", Cmd: "a"}, {Name: "b", Cmd: "b"}, {Name: "c", Cmd: "c"}, {Name: "d", Cmd: "d"}}},
+		{[]Cmd{{Name: "empty", Children: []Cmd{}}}, []Cmd{{Name: "empty", Children: []Cmd{}}}},
+	}
+	for _, tt := range tests {
+		Equals(t, fmt.Sprintf("commands: %v", tt.commands), tt.wat, flattenCommands(tt.commands))
+	}
+}
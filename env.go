@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// commandContext resolves the working directory and the environment cmd runs with.
+// ~ and $VARS are expanded in dir, env_file and env values, relative paths are relative to the
+// directory of the file defining the command. An empty dir means the working directory of c.
+func commandContext(cmd Cmd) (string, []string, error) {
+	env := newEnvironment(os.Environ())
+	dir := commandDir(cmd)
+
+	if cmd.EnvFile != "" {
+		envFile := resolvePath(commandBaseDir(cmd), env.expand(cmd.EnvFile))
+		if err := env.loadFile(envFile); err != nil {
+			return "", nil, err
+		}
+	}
+
+	names := make([]string, 0, len(cmd.Env))
+	for name := range cmd.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env.set(name, env.expand(cmd.Env[name]))
+	}
+	return dir, env.list(), nil
+}
+
+// commandDir resolves the working directory of cmd like commandContext, without reading its env_file.
+func commandDir(cmd Cmd) string {
+	if cmd.Dir == "" {
+		return ""
+	}
+	return resolvePath(commandBaseDir(cmd), newEnvironment(os.Environ()).expand(cmd.Dir))
+}
+
+// commandBaseDir is the directory relative paths of cmd are relative to.
+func commandBaseDir(cmd Cmd) string {
+	if cmd.Source != "" {
+		return filepath.Dir(cmd.Source)
+	}
+	return filepath.Dir(configFile)
+}
+
+func resolvePath(baseDir string, path string) string {
+	path = expandHome(path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// expandHome replaces a leading ~ of path by the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// environment is an ordered set of NAME=value variables.
+type environment struct {
+	names  []string
+	values map[string]string
+}
+
+func newEnvironment(vars []string) *environment {
+	env := &environment{values: make(map[string]string)}
+	for _, v := range vars {
+		if i := strings.Index(v, "="); i > 0 {
+			env.set(v[:i], v[i+1:])
+		}
+	}
+	return env
+}
+
+func (env *environment) set(name string, value string) {
+	if _, ok := env.values[name]; !ok {
+		env.names = append(env.names, name)
+	}
+	env.values[name] = value
+}
+
+func (env *environment) expand(s string) string {
+	return os.Expand(s, func(name string) string {
+		return env.values[name]
+	})
+}
+
+func (env *environment) list() []string {
+	vars := make([]string, 0, len(env.names))
+	for _, name := range env.names {
+		vars = append(vars, name+"="+env.values[name])
+	}
+	return vars
+}
+
+// loadFile reads a .env file: NAME=value lines with an optional export prefix and # comments.
+// Values in single quotes are literal, other values have their $VARS expanded.
+func (env *environment) loadFile(file string) error {
+	fd, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return fmt.Errorf("%s:%d: expected NAME=value", file, lineNum)
+		}
+		name, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			value = env.expand(value)
+		default:
+			if j := strings.Index(value, " #"); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+			value = env.expand(value)
+		}
+		env.set(name, value)
+	}
+	return scanner.Err()
+}
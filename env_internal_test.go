@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-env")
+	Equals(t, "create temp dir", nil, err)
+	defer os.RemoveAll(dir)
+
+	envFile := `# comment
+export APP_ENV=prod
+DB_URL="postgres://$DB_HOST/app"
+RAW='$NOT_EXPANDED'
+NAME=web # trailing comment
+`
+	Equals(t, "write env file", nil, ioutil.WriteFile(filepath.Join(dir, ".env"), []byte(envFile), 0600))
+	Equals(t, "set DB_HOST", nil, os.Setenv("DB_HOST", "db.local"))
+	defer os.Unsetenv("DB_HOST")
+
+	cmd := Cmd{
+		Name:    "deploy",
+		Source:  filepath.Join(dir, projectConfigName),
+		Dir:     "build",
+		EnvFile: ".env",
+		Env:     map[string]string{"TARGET": "$APP_ENV-$NAME", "DB_HOST": "override"},
+	}
+	gotDir, env, err := commandContext(cmd)
+	Equals(t, "command context", nil, err)
+	Equals(t, "dir", filepath.Join(dir, "build"), gotDir)
+
+	got := newEnvironment(env).values
+	var tests = []struct {
+		name string
+		wat  string
+	}{
+		{"APP_ENV", "prod"},
+		{"DB_URL", "postgres://db.local/app"},
+		{"RAW", "$NOT_EXPANDED"},
+		{"NAME", "web"},
+		{"TARGET", "prod-web"},
+		{"DB_HOST", "override"},
+	}
+	for _, tt := range tests {
+		msg := fmt.Sprintf("env: %s", tt.name)
+		Equals(t, msg, tt.wat, got[tt.name])
+	}
+}
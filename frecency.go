@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type orderMode int
+
+const (
+	ConfigOrder orderMode = iota
+	AlphabeticalOrder
+	FrecencyOrder
+)
+
+func (o orderMode) String() string {
+	switch o {
+	case AlphabeticalOrder:
+		return "a-z"
+	case FrecencyOrder:
+		return "frecency"
+	default:
+		return "config"
+	}
+}
+
+func (o orderMode) next() orderMode {
+	return (o + 1) % (FrecencyOrder + 1)
+}
+
+// Usage records how often and how recently a command was used.
+type Usage struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// UsageStore maps usageKey of a command to its usage.
+type UsageStore map[string]Usage
+
+func usageFile() string {
+	return filepath.Join(filepath.Dir(configFile), ".c.usage.json")
+}
+
+// usageKey identifies a command of the config by its group path and name.
+func usageKey(cmd Cmd) string {
+	return strings.Join(append(append([]string{}, cmd.Path...), cmd.Name), " / ")
+}
+
+func loadUsage() UsageStore {
+	usage := make(UsageStore)
+	data, err := ioutil.ReadFile(filepath.Clean(usageFile()))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug("Load usage get: %v", err)
+		}
+		return usage
+	}
+	if err = json.Unmarshal(data, &usage); err != nil {
+		debug("Parse usage get: %v, start from scratch.", err)
+		return make(UsageStore)
+	}
+	return usage
+}
+
+func (us UsageStore) save() error {
+	data, err := json.Marshal(us)
+	if err != nil {
+		return err
+	}
+	// write then rename, so an interrupted write never loses the whole store
+	tmpFile := usageFile() + ".tmp"
+	if err = ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, usageFile())
+}
+
+// recordUsage counts one more use of cmd, failing to do so is only logged.
+func recordUsage(cmd Cmd) {
+	usage := loadUsage()
+	key := usageKey(cmd)
+	record := usage[key]
+	record.Count++
+	record.LastUsed = time.Now()
+	usage[key] = record
+	if err := usage.save(); err != nil {
+		debug("Save usage get: %v", err)
+	}
+}
+
+// score weights the use count by how recently the command was last used, groups sum their children.
+func (us UsageStore) score(cmd Cmd, now time.Time) float64 {
+	if cmd.IsGroup() {
+		var total float64
+		for _, child := range cmd.Children {
+			total += us.score(child, now)
+		}
+		return total
+	}
+
+	record, ok := us[usageKey(cmd)]
+	if !ok {
+		return 0
+	}
+	age := now.Sub(record.LastUsed)
+	switch {
+	case age < time.Hour:
+		return float64(record.Count) * 4
+	case age < 24*time.Hour:
+		return float64(record.Count) * 2
+	case age < 7*24*time.Hour:
+		return float64(record.Count) * 0.5
+	default:
+		return float64(record.Count) * 0.25
+	}
+}
+
+// sortCommands returns a copy of commands in the given order, config order keeps them as they are.
+func sortCommands(commands []Cmd, order orderMode, usage UsageStore) []Cmd {
+	sorted := append([]Cmd{}, commands...)
+	switch order {
+	case AlphabeticalOrder:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+		})
+	case FrecencyOrder:
+		now := time.Now()
+		scores := make([]float64, len(sorted))
+		for i, cmd := range sorted {
+			scores[i] = usage.score(cmd, now)
+		}
+		sort.Stable(byScore{sorted, scores})
+	}
+	return sorted
+}
+
+type byScore struct {
+	commands []Cmd
+	scores   []float64
+}
+
+func (b byScore) Len() int           { return len(b.commands) }
+func (b byScore) Less(i, j int) bool { return b.scores[i] > b.scores[j] }
+func (b byScore) Swap(i, j int) {
+	b.commands[i], b.commands[j] = b.commands[j], b.commands[i]
+	b.scores[i], b.scores[j] = b.scores[j], b.scores[i]
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSortCommands(t *testing.T) {
+	now := time.Now()
+	commands := annotatePaths([]Cmd{
+		{Name: "deploy", Cmd: "make deploy"},
+		{Name: "Build", Cmd: "make build"},
+		{Name: "db", Children: []Cmd{{Name: "connect", Cmd: "psql"}}},
+		{Name: "clean", Cmd: "make clean"},
+	}, nil)
+	usage := UsageStore{
+		"deploy":       {Count: 10, LastUsed: now.Add(-30 * 24 * time.Hour)},
+		"clean":        {Count: 2, LastUsed: now.Add(-time.Minute)},
+		"db / connect": {Count: 1, LastUsed: now.Add(-2 * time.Hour)},
+	}
+
+	var tests = []struct {
+		order orderMode
+		wat   []string
+	}{
+		{ConfigOrder, []string{"deploy", "Build", "db", "clean"}},
+		{AlphabeticalOrder, []string{"Build", "clean", "db", "deploy"}},
+		{FrecencyOrder, []string{"clean", "deploy", "db", "Build"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, cmd := range sortCommands(commands, tt.order, usage) {
+			got = append(got, cmd.Name)
+		}
+		msg := fmt.Sprintf("order: %s", tt.order)
+		Equals(t, msg, tt.wat, got)
+	}
+}
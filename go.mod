@@ -10,5 +10,7 @@ require (
 	github.com/micmonay/keybd_event v1.0.1
 	github.com/onsi/ginkgo v1.12.0
 	github.com/onsi/gomega v1.9.0
-	gopkg.in/yaml.v2 v2.2.8
+	golang.org/x/text v0.3.2
+	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const historyLimit = 200
+
+// HistoryEntry is one line of the history file, ExitCode and DurationMs are unknown
+// when the command replaced c through syscall.Exec.
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Name       string    `json:"name"`
+	Path       []string  `json:"path,omitempty"`
+	Cmd        string    `json:"cmd"`
+	Cwd        string    `json:"cwd"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	DurationMs *int64    `json:"duration_ms,omitempty"`
+}
+
+func historyFile() string {
+	return filepath.Join(filepath.Dir(configFile), ".c.history.jsonl")
+}
+
+// newHistoryEntry records cmd running in dir, an empty dir is the working directory of c.
+func newHistoryEntry(cmd Cmd, dir string) HistoryEntry {
+	cwd := dir
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	return HistoryEntry{Time: time.Now(), Name: cmd.Name, Path: cmd.Path, Cmd: cmd.Script(), Cwd: cwd}
+}
+
+func (h HistoryEntry) withResult(result RunResult) HistoryEntry {
+	exitCode := result.ExitCode
+	durationMs := int64(result.Duration / time.Millisecond)
+	h.ExitCode = &exitCode
+	h.DurationMs = &durationMs
+	return h
+}
+
+// recordHistory appends a history entry, failing to do so must never prevent a command from running.
+func recordHistory(entry HistoryEntry) {
+	if err := appendHistory(entry); err != nil {
+		debug("Append history get: %v", err)
+		color.Yellow("Failed to record history: %v", err)
+	}
+}
+
+// appendHistory adds entry at the end of the history file, the file is never rewritten.
+func appendHistory(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	fd, err := os.OpenFile(historyFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = fd.Write(append(data, '\n')); err != nil {
+		_ = fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// loadHistory returns at most limit entries, the most recent first. Malformed lines are skipped.
+func loadHistory(limit int) ([]HistoryEntry, error) {
+	fd, err := os.Open(filepath.Clean(historyFile()))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(fd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			debug("Skip malformed history line: %v", err)
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
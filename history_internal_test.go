@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryKeepsMostRecentEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-history")
+	Equals(t, "create temp dir", nil, err)
+	defer os.RemoveAll(dir)
+
+	defaultConfigFile := configFile
+	configFile = filepath.Join(dir, ".c.conf")
+	defer func() { configFile = defaultConfigFile }()
+
+	for i := 0; i < 5; i++ {
+		entry := newHistoryEntry(Cmd{Name: fmt.Sprintf("cmd%d", i), Cmd: "date"}, dir)
+		if i%2 == 0 {
+			entry = entry.withResult(RunResult{ExitCode: i})
+		}
+		Equals(t, "append history", nil, appendHistory(entry))
+	}
+
+	entries, err := loadHistory(3)
+	Equals(t, "load history", nil, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	Equals(t, "history order", []string{"cmd4", "cmd3", "cmd2"}, names)
+	Equals(t, "exit code of cmd4", 4, *entries[0].ExitCode)
+	Equals(t, "exit code of cmd3", (*int)(nil), entries[1].ExitCode)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// keyAction is a named operation of the list, keys are bound to actions by a keymap.
+type keyAction string
+
+const (
+	actionNone            keyAction = "none"
+	actionScrollDown      keyAction = "scroll-down"
+	actionScrollUp        keyAction = "scroll-up"
+	actionHalfPageDown    keyAction = "half-page-down"
+	actionHalfPageUp      keyAction = "half-page-up"
+	actionPageDown        keyAction = "page-down"
+	actionPageUp          keyAction = "page-up"
+	actionSearch          keyAction = "search"
+	actionSelect          keyAction = "select"
+	actionOpen            keyAction = "open"
+	actionBack            keyAction = "back"
+	actionOrder           keyAction = "order"
+	actionMatcher         keyAction = "matcher"
+	actionHistory         keyAction = "history"
+	actionTags            keyAction = "tags"
+	actionMark            keyAction = "mark"
+	actionRunParallel     keyAction = "run-parallel"
+	actionPreview         keyAction = "preview"
+	actionPreviewWider    keyAction = "preview-wider"
+	actionPreviewNarrower keyAction = "preview-narrower"
+	actionErase           keyAction = "erase"
+	actionDeleteChar      keyAction = "delete-char"
+	actionPick            keyAction = "pick"
+	actionClear           keyAction = "clear"
+	actionQuit            keyAction = "quit"
+	actionCancel          keyAction = "cancel"
+	actionRsync           keyAction = "rsync"
+	actionParent          keyAction = "parent"
+	actionHidden          keyAction = "hidden"
+	actionDownload        keyAction = "download"
+)
+
+// keymapModes are the modes with configurable keys, by their name in the keymap section of the config.
+var keymapModes = map[string]listMode{
+	"normal":  NormalMode,
+	"search":  SearchMode,
+	"history": HistoryMode,
+	"tags":    TagMode,
+	"files":   FileMode,
+}
+
+// keyBinding binds a termui event ID, like "j" or "<C-r>", to an action.
+type keyBinding struct {
+	key    string
+	action keyAction
+}
+
+// defaultBindings are the keys of each mode, every action of a mode has at least one.
+var defaultBindings = map[listMode][]keyBinding{
+	NormalMode: {
+		{"j", actionScrollDown}, {"<Down>", actionScrollDown}, {"k", actionScrollUp}, {"<Up>", actionScrollUp},
+		{"<C-d>", actionHalfPageDown}, {"<C-u>", actionHalfPageUp}, {"<C-f>", actionPageDown}, {"<C-b>", actionPageUp},
+		{"/", actionSearch}, {"<Enter>", actionSelect}, {"l", actionOpen}, {"h", actionBack}, {"<Backspace>", actionBack},
+		{"o", actionOrder}, {"H", actionHistory}, {"t", actionTags}, {"<Space>", actionMark}, {"<Tab>", actionMark},
+		{"<C-p>", actionRunParallel}, {"p", actionPreview}, {"<", actionPreviewWider}, {">", actionPreviewNarrower},
+		{"q", actionQuit}, {"<C-c>", actionQuit}, {"<Escape>", actionCancel}, {"<C-r>", actionRsync},
+		{"<C-g>", actionDownload},
+	},
+	SearchMode: {
+		{"<C-j>", actionScrollDown}, {"<Down>", actionScrollDown}, {"<C-k>", actionScrollUp}, {"<Up>", actionScrollUp},
+		{"<Enter>", actionSelect}, {"<C-u>", actionErase}, {"<Backspace>", actionDeleteChar},
+		{"<C-o>", actionOrder}, {"<C-t>", actionMatcher}, {"<Tab>", actionMark}, {"<C-p>", actionRunParallel},
+		{"<C-v>", actionPreview}, {"<C-c>", actionBack}, {"<Escape>", actionBack}, {"<C-r>", actionRsync},
+		{"<C-g>", actionDownload},
+	},
+	HistoryMode: {
+		{"j", actionScrollDown}, {"<Down>", actionScrollDown}, {"k", actionScrollUp}, {"<Up>", actionScrollUp},
+		{"<C-d>", actionHalfPageDown}, {"<C-u>", actionHalfPageUp}, {"<C-f>", actionPageDown}, {"<C-b>", actionPageUp},
+		{"<Enter>", actionSelect}, {"q", actionBack}, {"H", actionBack}, {"<C-c>", actionBack}, {"<Escape>", actionBack},
+	},
+	TagMode: {
+		{"j", actionScrollDown}, {"<Down>", actionScrollDown}, {"k", actionScrollUp}, {"<Up>", actionScrollUp},
+		{"<Space>", actionPick}, {"<Enter>", actionPick}, {"c", actionClear},
+		{"t", actionBack}, {"q", actionBack}, {"<C-c>", actionBack}, {"<Escape>", actionBack},
+	},
+	FileMode: {
+		{"<C-j>", actionScrollDown}, {"<Down>", actionScrollDown}, {"<C-k>", actionScrollUp}, {"<Up>", actionScrollUp},
+		{"<Enter>", actionSelect}, {"<Right>", actionOpen}, {"<Left>", actionParent}, {"<Tab>", actionPick},
+		{"<C-t>", actionHidden}, {"<C-u>", actionErase}, {"<Backspace>", actionDeleteChar},
+		{"<C-c>", actionBack}, {"<Escape>", actionBack},
+	},
+}
+
+// keyHint is one "(label:keys)" hint of a title, a hint of several actions shows one key of each.
+type keyHint struct {
+	label   string
+	actions []keyAction
+}
+
+var keyHints = map[listMode][]keyHint{
+	NormalMode: {
+		{"Search", []keyAction{actionSearch}}, {"Up/Down", []keyAction{actionScrollUp, actionScrollDown}},
+		{"Open/Back", []keyAction{actionOpen, actionBack}}, {"Order", []keyAction{actionOrder}},
+		{"History", []keyAction{actionHistory}}, {"Tags", []keyAction{actionTags}}, {"Mark", []keyAction{actionMark}},
+		{"Run parallel", []keyAction{actionRunParallel}}, {"Preview", []keyAction{actionPreview}},
+		{"Exit", []keyAction{actionQuit}}, {"Rsync", []keyAction{actionRsync}}, {"Download", []keyAction{actionDownload}},
+	},
+	SearchMode: {
+		{"Up/Down", []keyAction{actionScrollUp, actionScrollDown}}, {"Exit", []keyAction{actionBack}},
+		{"Erase", []keyAction{actionErase}}, {"Order", []keyAction{actionOrder}}, {"Matcher", []keyAction{actionMatcher}},
+		{"Mark", []keyAction{actionMark}}, {"Run parallel", []keyAction{actionRunParallel}},
+		{"Preview", []keyAction{actionPreview}}, {"Rsync", []keyAction{actionRsync}}, {"Download", []keyAction{actionDownload}},
+	},
+	HistoryMode: {
+		{"Up/Down", []keyAction{actionScrollUp, actionScrollDown}}, {"Re-run", []keyAction{actionSelect}},
+		{"Back", []keyAction{actionBack}},
+	},
+	TagMode: {
+		{"Up/Down", []keyAction{actionScrollUp, actionScrollDown}}, {"Pick", []keyAction{actionPick}},
+		{"Clear", []keyAction{actionClear}}, {"Back", []keyAction{actionBack}},
+	},
+	FileMode: {
+		{"Up/Down", []keyAction{actionScrollUp, actionScrollDown}}, {"Choose", []keyAction{actionSelect}},
+		{"Open/Parent", []keyAction{actionOpen, actionParent}}, {"Confirm", []keyAction{actionPick}},
+		{"Hidden", []keyAction{actionHidden}}, {"Erase", []keyAction{actionErase}}, {"Cancel", []keyAction{actionBack}},
+	},
+}
+
+// keymap holds the bindings of each mode, in the order their keys are shown in hints.
+type keymap map[listMode][]keyBinding
+
+func defaultKeymap() keymap {
+	km, _ := newKeymap(nil)
+	return km
+}
+
+// newKeymap applies the keymap section of the config over defaultBindings. A key bound to "none"
+// is unbound. Bindings are checked by checkBinding, the first invalid one is returned as an error.
+func newKeymap(config map[string]map[string]string) (keymap, error) {
+	km := make(keymap, len(defaultBindings))
+	for mode, bindings := range defaultBindings {
+		km[mode] = append([]keyBinding{}, bindings...)
+	}
+
+	modeNames := make([]string, 0, len(config))
+	for modeName := range config {
+		modeNames = append(modeNames, modeName)
+	}
+	sort.Strings(modeNames)
+	for _, modeName := range modeNames {
+		keys := make([]string, 0, len(config[modeName]))
+		for key := range config[modeName] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			action := config[modeName][key]
+			if err := checkBinding(modeName, key, action); err != nil {
+				return nil, err
+			}
+			mode := keymapModes[modeName]
+			km[mode] = km.unbind(mode, key)
+			if keyAction(action) != actionNone {
+				km[mode] = append(km[mode], keyBinding{key, keyAction(action)})
+			}
+		}
+	}
+	return km, nil
+}
+
+func (km keymap) unbind(mode listMode, key string) []keyBinding {
+	var bindings []keyBinding
+	for _, binding := range km[mode] {
+		if binding.key != key {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+// checkBinding reports a key which cannot be bound in a mode: unknown mode or action, a key that is not
+// a termui event ID, or a key that conflicts with typing the search string or the file filter.
+func checkBinding(modeName string, key string, action string) error {
+	mode, ok := keymapModes[modeName]
+	if !ok {
+		return fmt.Errorf("unknown keymap mode %q, expected one of normal, search, history, tags, files", modeName)
+	}
+	if keyAction(action) != actionNone && !modeHasAction(mode, keyAction(action)) {
+		return fmt.Errorf("unknown action %q in %s mode", action, modeName)
+	}
+	if utf8.RuneCountInString(key) != 1 && !(strings.HasPrefix(key, "<") && strings.HasSuffix(key, ">") && len(key) > 2) {
+		return fmt.Errorf("invalid key %q, expected a character or an event ID like <C-x>", key)
+	}
+	if key == "<Resize>" {
+		return fmt.Errorf("%s cannot be bound", key)
+	}
+	if mode == SearchMode && (utf8.RuneCountInString(key) == 1 || key == "<Space>") {
+		return fmt.Errorf("key %q conflicts with typing the search string", key)
+	}
+	if mode == FileMode && (utf8.RuneCountInString(key) == 1 || key == "<Space>") {
+		return fmt.Errorf("key %q conflicts with typing the file filter", key)
+	}
+	return nil
+}
+
+func modeHasAction(mode listMode, action keyAction) bool {
+	for _, binding := range defaultBindings[mode] {
+		if binding.action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// action returns the action bound to key in mode, empty when the key is not bound.
+func (km keymap) action(mode listMode, key string) keyAction {
+	for _, binding := range km[mode] {
+		if binding.key == key {
+			return binding.action
+		}
+	}
+	return ""
+}
+
+func (km keymap) keys(mode listMode, action keyAction) []string {
+	var keys []string
+	for _, binding := range km[mode] {
+		if binding.action == action {
+			keys = append(keys, binding.key)
+		}
+	}
+	return keys
+}
+
+// hints renders the usage hints of mode from the bound keys, actions without keys are left out.
+func (km keymap) hints(mode listMode) string {
+	var hints []string
+	for _, hint := range keyHints[mode] {
+		var keys []string
+		for _, action := range hint.actions {
+			bound := km.keys(mode, action)
+			if len(bound) == 0 {
+				continue
+			}
+			if len(hint.actions) > 1 {
+				bound = bound[:1]
+			}
+			for _, key := range bound {
+				keys = append(keys, displayKey(key))
+			}
+		}
+		if len(keys) > 0 {
+			hints = append(hints, fmt.Sprintf("(%s:%s)", hint.label, strings.Join(keys, "/")))
+		}
+	}
+	return strings.Join(hints, " ")
+}
+
+func displayKey(key string) string {
+	if strings.HasPrefix(key, "<") && len(key) > 1 {
+		return strings.Replace(key, "<Escape>", "<Esc>", 1)
+	}
+	return "<" + key + ">"
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewKeymap(t *testing.T) {
+	km, err := newKeymap(map[string]map[string]string{
+		"normal": {"<C-n>": "scroll-down", "j": "none", "x": "quit"},
+		"search": {"<C-n>": "scroll-down"},
+	})
+	Equals(t, "error", nil, err)
+	Equals(t, "rebound", actionScrollDown, km.action(NormalMode, "<C-n>"))
+	Equals(t, "unbound", keyAction(""), km.action(NormalMode, "j"))
+	Equals(t, "default", actionScrollUp, km.action(NormalMode, "k"))
+	Equals(t, "search", actionScrollDown, km.action(SearchMode, "<C-n>"))
+	Equals(t, "untouched mode", actionScrollDown, km.action(HistoryMode, "j"))
+	Equals(t, "hints", "(Up/Down:<k>/<j>) (Re-run:<Enter>) (Back:<q>/<H>/<C-c>/<Esc>)", km.hints(HistoryMode))
+	Equals(t, "hints", true, strings.Contains(km.hints(NormalMode), "(Up/Down:<k>/<Down>)"))
+	Equals(t, "hints", true, strings.Contains(km.hints(NormalMode), "(Exit:<q>/<C-c>/<x>)"))
+}
+
+func TestMarkKeys(t *testing.T) {
+	km := defaultKeymap()
+	Equals(t, "normal <Space>", actionMark, km.action(NormalMode, "<Space>"))
+	Equals(t, "normal <Tab>", actionMark, km.action(NormalMode, "<Tab>"))
+	Equals(t, "search <Tab>", actionMark, km.action(SearchMode, "<Tab>"))
+	// <Space> separates the terms of the search string, it cannot mark in search mode
+	Equals(t, "search <Space>", keyAction(""), km.action(SearchMode, "<Space>"))
+	Equals(t, "search <Space> binding", errors.New(`key "<Space>" conflicts with typing the search string`),
+		checkBinding("search", "<Space>", "mark"))
+}
+
+func TestCheckBinding(t *testing.T) {
+	var tests = []struct {
+		mode   string
+		key    string
+		action string
+		wat    error
+	}{
+		{"normal", "<C-n>", "scroll-down", nil},
+		{"normal", "x", "none", nil},
+		{"search", "<C-n>", "matcher", nil},
+		{"insert", "x", "quit", errors.New(`unknown keymap mode "insert", expected one of normal, search, history, tags, files`)},
+		{"normal", "x", "matcher", errors.New(`unknown action "matcher" in normal mode`)},
+		{"normal", "C-x", "quit", errors.New(`invalid key "C-x", expected a character or an event ID like <C-x>`)},
+		{"normal", "<Resize>", "quit", errors.New(`<Resize> cannot be bound`)},
+		{"search", "x", "quit", errors.New(`unknown action "quit" in search mode`)},
+		{"search", "x", "back", errors.New(`key "x" conflicts with typing the search string`)},
+		{"search", "<Space>", "mark", errors.New(`key "<Space>" conflicts with typing the search string`)},
+	}
+	for _, tt := range tests {
+		msg := fmt.Sprintf("mode: %s, key: %s, action: %s", tt.mode, tt.key, tt.action)
+		Equals(t, msg, tt.wat, checkBinding(tt.mode, tt.key, tt.action))
+	}
+}
@@ -5,11 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	ui "github.com/fedomn/termui/v3"
 	"github.com/fedomn/termui/v3/widgets"
-	"github.com/lithammer/fuzzysearch/fuzzy"
 )
 
 type listMode int
@@ -17,17 +18,52 @@ type listMode int
 const (
 	NormalMode listMode = iota
 	SearchMode
+	PromptMode
+	HistoryMode
+	TagMode
+	FileMode
 )
 
+// groupLevel remembers a parent group while the list is showing one of its children,
+// items are kept in config order.
+type groupLevel struct {
+	name        string
+	items       []Cmd
+	selectedRow int
+}
+
 type SelectList struct {
+	levelItems          []Cmd
 	normalItems         []Cmd
 	searchItems         []Cmd
+	allItems            []Cmd
+	historyItems        []HistoryEntry
+	groupStack          []groupLevel
 	uiList              *widgets.List
+	tagList             *widgets.List
+	tags                []tagCount
+	tagFilter           []string
+	preview             *widgets.Paragraph
+	showPreview         bool
+	previewWidth        int
+	lastRuns            map[string]HistoryEntry
 	selectedMode        listMode
+	order               orderMode
+	usage               UsageStore
 	selectedCommandChan chan<- Cmd
+	keymap              keymap
 	normalTitle         string
 	searchTitle         string
+	promptTitle         string
+	confirmTitle        string
+	historyTitle        string
+	tagTitle            string
+	fileTitle           string
 	searchStr           string
+	matcherIndex        int
+	marked              []Cmd
+	prompt              *promptForm
+	files               *filePicker
 	isClose             bool
 	rsyncUploader       RsyncUploader
 }
@@ -37,22 +73,43 @@ func NewUIList(items []Cmd, selectedCommandChan chan<- Cmd) *SelectList {
 		color.Red("Cmd list is empty, please fill in your configuration first.")
 		os.Exit(1)
 	}
+	items = annotatePaths(items, nil)
 	selectList := &SelectList{
+		levelItems:          items,
 		normalItems:         items,
-		searchItems:         items,
+		searchItems:         flattenCommands(items),
+		allItems:            flattenCommands(items),
 		uiList:              widgets.NewList(),
 		selectedMode:        NormalMode,
+		previewWidth:        defaultPreviewWidth,
+		order:               ConfigOrder,
+		usage:               loadUsage(),
 		selectedCommandChan: selectedCommandChan,
-		normalTitle:         "Usage: (Search:</>) (Up/Down:<k>/<j>) (Exit:<C-c>/<Esc>) (Rsync:<C-r>)",
-		searchTitle:         "Search: [%s](fg:red)  |  Usage: (Up/Down:<C-k>/<C-j>) (Exit:<C-c>/<Esc>) (Erase:<C-u>) (Rsync:<C-r>)",
+		promptTitle:         "Fill in: %s  |  Usage: (Next/Prev:<Tab>/<Up>) (Confirm:<Enter>) (Erase:<C-u>) (Cancel:<C-c>/<Esc>)",
+		confirmTitle:        "Confirm: %s  |  Usage: (Run:<Enter>) (Erase:<C-u>) (Cancel:<C-c>/<Esc>)",
 		isClose:             false,
 	}
+	selectList.setKeymap(defaultKeymap())
 	selectList.initUI()
+	selectList.loadLastRuns()
 	selectList.resizeUI()
 	selectList.renderUI()
 	return selectList
 }
 
+// setKeymap binds the keys of the list and builds the usage hints of the titles from them.
+func (sl *SelectList) setKeymap(km keymap) {
+	sl.keymap = km
+	sl.normalTitle = "Usage: " + km.hints(NormalMode)
+	sl.searchTitle = "Search: %s  |  Matcher: %s  |  Usage: " + strings.Replace(km.hints(SearchMode), "%", "%%", -1)
+	sl.historyTitle = "History  |  Usage: " + km.hints(HistoryMode)
+	sl.tagTitle = "Tags  |  Usage: " + km.hints(TagMode)
+	sl.fileTitle = "Upload: %s  |  Filter: %s  |  Usage: " + strings.Replace(km.hints(FileMode), "%", "%%", -1)
+	if sl.uiList != nil {
+		sl.refreshTitle()
+	}
+}
+
 func (sl *SelectList) registerRsyncUploader(rsyncUploader RsyncUploader) {
 	sl.rsyncUploader = rsyncUploader
 }
@@ -63,53 +120,157 @@ func (sl *SelectList) initUI() {
 		os.Exit(1)
 	}
 	uiList := widgets.NewList()
-	uiList.Title = sl.normalTitle
-	uiList.TitleStyle = ui.NewStyle(ui.ColorBlue, ui.ColorClear, ui.ModifierBold)
-	uiList.BorderStyle = ui.NewStyle(ui.ColorWhite)
-	uiList.TextStyle = ui.NewStyle(ui.ColorCyan)
+	uiList.Title = sl.normalTitle + sl.statusTitle()
+	styleBlock(&uiList.Block)
+	uiList.TextStyle = ui.NewStyle(termColor(theme.Text))
 	uiList.WrapText = false
 
+	preview := widgets.NewParagraph()
+	preview.Title = "Details"
+	styleBlock(&preview.Block)
+	preview.TextStyle = ui.NewStyle(termColor(theme.Text))
+	preview.WrapText = true
+
+	tagList := widgets.NewList()
+	tagList.Title = "Tags"
+	styleBlock(&tagList.Block)
+	tagList.TextStyle = ui.NewStyle(termColor(theme.Text))
+	tagList.WrapText = false
+
 	sl.uiList = uiList
+	sl.tagList = tagList
+	sl.preview = preview
 	debug("Init uiList successfully.")
 }
 
-func (sl *SelectList) resizeUI() {
+// layoutUI splits the terminal between the tag panel, the list and the preview pane when they are visible.
+func (sl *SelectList) layoutUI() {
 	termWidth, termHeight := ui.TerminalDimensions()
-	sl.uiList.SetRect(0, 0, termWidth, termHeight)
+	left, right := 0, termWidth
+	if sl.selectedMode == TagMode {
+		left = termWidth * tagPanelWidth / 100
+		sl.tagList.SetRect(0, 0, left, termHeight)
+	}
+	if sl.previewVisible() {
+		right = termWidth * (100 - sl.previewWidth) / 100
+		sl.preview.SetRect(right, 0, termWidth, termHeight)
+	}
+	sl.uiList.SetRect(left, 0, right, termHeight)
+}
+
+func (sl *SelectList) resizeUI() {
+	sl.layoutUI()
 	debug("Resize uiList successfully.")
 }
 
 func (sl *SelectList) renderUI() {
+	if sl.isClose {
+		return
+	}
+	// a long rsync command is shown in full before it runs
+	sl.uiList.WrapText = sl.selectedMode == PromptMode && sl.prompt.kind == rsyncPrompt
+	switch sl.selectedMode {
+	case PromptMode:
+		sl.uiList.SelectedRow = sl.prompt.field
+		sl.uiList.Rows = sl.promptRows()
+	case HistoryMode:
+		sl.uiList.Rows = sl.historyRows()
+	case FileMode:
+		sl.uiList.Rows = sl.fileRows()
+	default:
+		sl.uiList.Rows = sl.commandRows()
+	}
+	// the preview hides in the prompt form and the history, the tag panel only shows in TagMode
+	sl.layoutUI()
+	drawables := []ui.Drawable{framed(sl.uiList, &sl.uiList.Block)}
+	if sl.selectedMode == TagMode {
+		sl.tagList.Rows = sl.tagRows()
+		drawables = append(drawables, framed(sl.tagList, &sl.tagList.Block))
+	}
+	if sl.previewVisible() {
+		sl.preview.Text = sl.previewText()
+		drawables = append(drawables, framed(sl.preview, &sl.preview.Block))
+	}
+	ui.Render(drawables...)
+	debug("Render uiList successfully. Selected Row Index: %v", sl.uiList.SelectedRow)
+}
+
+func (sl *SelectList) commandRows() []string {
 	var rows []string
 	var items []Cmd
-	if sl.selectedMode == NormalMode {
+	if sl.selectedMode == NormalMode || sl.selectedMode == TagMode {
 		items = sl.normalItems
 	} else if sl.selectedMode == SearchMode {
 		items = sl.searchItems
 	}
 	for k, v := range items {
+		name := v.Name
+		if sl.selectedMode == SearchMode {
+			name = v.FullName()
+		}
+		mark := ""
+		if len(sl.marked) > 0 {
+			mark = "  "
+			if sl.markIndex(v) >= 0 {
+				mark = styled("*", theme.Label, "bold") + " "
+			}
+		}
+		label := ""
+		if v.Source != "" {
+			label = " " + styled(v.sourceLabel(), theme.Label)
+		}
+		if len(v.Tags) > 0 {
+			label += " " + tagBadges(v.Tags)
+		}
+		if v.IsGroup() {
+			if k == sl.uiList.SelectedRow {
+				rows = append(rows, fmt.Sprintf("%s %s%s%s %s %s", styled(fmt.Sprintf("[%02d]", k), theme.Selected), mark,
+					styled(name+"/", theme.Group, "underline"), label, styled("-", theme.Info, "bold"),
+					styled(fmt.Sprintf("%d items", len(v.Children)), theme.Group, "bold")))
+			} else {
+				rows = append(rows, fmt.Sprintf("[%02d] %s%s%s", k, mark, styled(name+"/", theme.Group), label))
+			}
+			continue
+		}
+
+		namePositions, cmdPositions := sl.searchPositions(v)
 		if k == sl.uiList.SelectedRow {
-			format := "[[%02d]](fg:green) [%s](fg:green,mod:underline) [-](fg:cyan,mod:bold) [%s](fg:green,mod:bold)"
-			rows = append(rows, fmt.Sprintf(format, k, v.Name, v.Cmd))
+			name = highlight(name, namePositions, style(theme.Selected, "underline"), style(theme.Accent, "bold", "underline"))
+			script := highlight(v.Script(), cmdPositions, style(theme.Selected, "bold"), style(theme.Accent, "bold"))
+			rows = append(rows, fmt.Sprintf("%s %s%s%s %s %s", styled(fmt.Sprintf("[%02d]", k), theme.Selected), mark,
+				name, label, styled("-", theme.Info, "bold"), script))
+		} else if mark != "  " && mark != "" {
+			name = highlight(name, namePositions, style(theme.Label), style(theme.Accent, "bold"))
+			rows = append(rows, fmt.Sprintf("[%02d] %s%s%s", k, mark, name, label))
 		} else {
-			rows = append(rows, fmt.Sprintf("[%02d] %s", k, v.Name))
+			name = highlight(name, namePositions, "", style(theme.Accent, "bold"))
+			rows = append(rows, fmt.Sprintf("[%02d] %s%s%s", k, mark, name, label))
 		}
 	}
-	sl.uiList.Rows = rows
-	ui.Render(sl.uiList)
-	debug("Render uiList successfully. Selected Row Index: %v", sl.uiList.SelectedRow)
+	return rows
+}
+
+// searchPositions returns the runes of the shown full name and of the cmd matched by the search terms.
+func (sl *SelectList) searchPositions(cmd Cmd) ([]int, []int) {
+	if sl.selectedMode != SearchMode || sl.searchStr == "" {
+		return nil, nil
+	}
+	hit, ok := matchCommand(sl.matcher(), parseQuery(sl.searchStr), cmd)
+	if !ok {
+		return nil, nil
+	}
+	positions := hit.namePositions
+	offset := utf8.RuneCountInString(cmd.FullName()) - utf8.RuneCountInString(cmd.Name)
+	for i := range positions {
+		positions[i] += offset
+	}
+	return positions, hit.cmdPositions
 }
 
 func (sl *SelectList) ListenEvents() {
 	uiEvents := ui.PollEvents()
 	for {
-		e := <-uiEvents
-		switch sl.selectedMode {
-		case NormalMode:
-			sl.handleEventsAtNormalMode(e)
-		case SearchMode:
-			sl.handleEventsAtSearchMode(e)
-		}
+		sl.handleEvent(<-uiEvents)
 	}
 }
 
@@ -121,115 +282,326 @@ func (sl *SelectList) listenEventsWithCancel(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case e := <-uiEvents:
-			switch sl.selectedMode {
-			case NormalMode:
-				sl.handleEventsAtNormalMode(e)
-			case SearchMode:
-				sl.handleEventsAtSearchMode(e)
-			}
+			sl.handleEvent(e)
 		}
 	}
 }
 
+func (sl *SelectList) handleEvent(e ui.Event) {
+	// Events such as <Resize> still arrive while a command runs in the foreground.
+	if sl.isClose {
+		return
+	}
+	switch sl.selectedMode {
+	case NormalMode:
+		sl.handleEventsAtNormalMode(e)
+	case SearchMode:
+		sl.handleEventsAtSearchMode(e)
+	case PromptMode:
+		sl.handleEventsAtPromptMode(e)
+	case HistoryMode:
+		sl.handleEventsAtHistoryMode(e)
+	case TagMode:
+		sl.handleEventsAtTagMode(e)
+	case FileMode:
+		sl.handleEventsAtFileMode(e)
+	}
+}
+
 func (sl *SelectList) handleEventsAtNormalMode(e ui.Event) {
 	debug("Normal Mode Event: %+v", e)
-	switch e.ID {
-	case "j", "<Down>":
+	if e.ID == "<Resize>" {
+		sl.resizeUI()
+		sl.renderUI()
+		return
+	}
+	switch sl.keymap.action(NormalMode, e.ID) {
+	case actionScrollDown:
 		sl.uiList.ScrollDown()
-	case "k", "<Up>":
+	case actionScrollUp:
 		sl.uiList.ScrollUp()
-	case "<C-d>":
+	case actionHalfPageDown:
 		sl.uiList.ScrollHalfPageDown()
-	case "<C-u>":
+	case actionHalfPageUp:
 		sl.uiList.ScrollHalfPageUp()
-	case "<C-f>":
+	case actionPageDown:
 		sl.uiList.ScrollPageDown()
-	case "<C-b>":
+	case actionPageUp:
 		sl.uiList.ScrollPageUp()
-	case "q", "<C-c>", "<Escape>":
+	case actionCancel:
+		if len(sl.marked) > 0 {
+			sl.clearMarks()
+			break
+		}
 		sl.close()
 		sl.selectedCommandChan <- Cmd{}
-	case "<Enter>":
+	case actionQuit:
 		sl.close()
-		sl.selectedCommandChan <- sl.normalItems[sl.uiList.SelectedRow]
-	case "<C-r>":
+		sl.selectedCommandChan <- Cmd{}
+	case actionMark:
+		sl.toggleMark()
+	case actionRunParallel:
+		sl.submitMarked(true)
+	case actionPreview:
+		sl.togglePreview()
+	case actionPreviewWider:
+		sl.resizePreview(previewWidthStep)
+	case actionPreviewNarrower:
+		sl.resizePreview(-previewWidthStep)
+	case actionSelect:
+		if len(sl.marked) > 0 {
+			sl.submitMarked(false)
+		} else if selectedCmd, ok := sl.selectedItem(); ok {
+			if selectedCmd.IsGroup() {
+				sl.enterGroup(selectedCmd)
+			} else {
+				sl.submit(selectedCmd)
+			}
+		}
+	case actionOpen:
+		if selectedCmd, ok := sl.selectedItem(); ok && selectedCmd.IsGroup() {
+			sl.enterGroup(selectedCmd)
+		}
+	case actionBack:
+		sl.leaveGroup()
+	case actionHistory:
+		sl.enterHistory()
+	case actionTags:
+		sl.enterTags()
+	case actionOrder:
+		sl.cycleOrder()
+	case actionRsync:
 		sl.rsync()
-	case "<Resize>":
-		sl.resizeUI()
-	case "/":
+	case actionDownload:
+		sl.download()
+	case actionSearch:
 		sl.selectedMode = SearchMode
-		sl.uiList.SelectedRow = 0
 		sl.setSearchTitle()
+		sl.doSearch()
 	}
 	sl.renderUI()
 }
 
 func (sl *SelectList) handleEventsAtSearchMode(e ui.Event) {
 	debug("Search Mode Event: %+v", e)
-	switch e.ID {
-	case "<Down>", "<C-j>":
+	if e.ID == "<Resize>" {
+		sl.resizeUI()
+		sl.renderUI()
+		return
+	}
+	switch sl.keymap.action(SearchMode, e.ID) {
+	case actionScrollDown:
 		if len(sl.searchItems) > 0 {
 			sl.uiList.ScrollDown()
 		}
-	case "<Up>", "<C-k>":
+	case actionScrollUp:
 		if len(sl.searchItems) > 0 {
 			sl.uiList.ScrollUp()
 		}
-	case "<C-u>":
+	case actionErase:
 		if len(sl.searchStr) != 0 {
 			sl.searchStr = ""
 			sl.setSearchTitle()
 			sl.doSearch()
 		}
-	case "<Resize>":
-		sl.resizeUI()
-	case "<Enter>":
-		if len(sl.searchItems) > 0 {
-			sl.close()
-			sl.selectedCommandChan <- sl.searchItems[sl.uiList.SelectedRow]
+	case actionSelect:
+		if len(sl.marked) > 0 {
+			sl.submitMarked(false)
+		} else if len(sl.searchItems) > 0 {
+			sl.submit(sl.searchItems[sl.uiList.SelectedRow])
 		}
-	case "<C-r>":
+	case actionMark:
+		sl.toggleMark()
+	case actionRunParallel:
+		sl.submitMarked(true)
+	case actionPreview:
+		sl.togglePreview()
+	case actionRsync:
 		sl.rsync()
-	case "<C-c>", "<Escape>":
+	case actionDownload:
+		sl.download()
+	case actionOrder:
+		sl.cycleOrder()
+	case actionMatcher:
+		sl.cycleMatcher()
+	case actionBack:
 		sl.selectedMode = NormalMode
 		sl.searchStr = ""
-		sl.uiList.Title = sl.normalTitle
+		sl.setNormalTitle()
 		sl.uiList.SelectedRow = 0
-		sl.searchItems = sl.normalItems
-	case "<Backspace>":
+		sl.searchItems = sl.allItems
+	case actionDeleteChar:
 		if len(sl.searchStr) > 0 {
 			sl.searchStr = sl.searchStr[:len(sl.searchStr)-1]
 			sl.setSearchTitle()
 			sl.doSearch()
 		}
-	case "<Space>":
-		sl.searchStr += " "
-		sl.setSearchTitle()
-		sl.doSearch()
 	default:
-		if len(e.ID) != 1 {
+		// keys without an action are typed into the search string
+		if e.ID == "<Space>" {
+			sl.searchStr += " "
+		} else if len(e.ID) == 1 {
+			sl.searchStr += e.ID
+		} else {
 			return
 		}
-		sl.searchStr += e.ID
 		sl.setSearchTitle()
 		sl.doSearch()
 	}
 	sl.renderUI()
 }
 
+func (sl *SelectList) statusTitle() string {
+	title := "  |  Order: " + styled(sl.order.String(), theme.Accent)
+	if len(sl.marked) > 0 {
+		title += "  |  Marked: " + styled(fmt.Sprint(len(sl.marked)), theme.Label)
+	}
+	return title + sl.tagFilterTitle()
+}
+
 func (sl *SelectList) setSearchTitle() {
-	sl.uiList.Title = fmt.Sprintf(sl.searchTitle, sl.searchStr)
+	sl.uiList.Title = fmt.Sprintf(sl.searchTitle, styled(sl.searchStr, theme.Accent), styled(sl.matcher().Name(), theme.Accent)) + sl.statusTitle()
+}
+
+func (sl *SelectList) matcher() matcher {
+	return matchers[sl.matcherIndex]
+}
+
+// cycleMatcher switches to the next match algorithm of matchers.
+func (sl *SelectList) cycleMatcher() {
+	sl.matcherIndex = (sl.matcherIndex + 1) % len(matchers)
+	sl.setSearchTitle()
+	sl.doSearch()
+}
+
+func (sl *SelectList) setNormalTitle() {
+	if len(sl.groupStack) == 0 {
+		sl.uiList.Title = sl.normalTitle + sl.statusTitle()
+		return
+	}
+	var groupPath []string
+	for _, level := range sl.groupStack {
+		groupPath = append(groupPath, level.name)
+	}
+	sl.uiList.Title = fmt.Sprintf("Group: %s  |  %s", styled(strings.Join(groupPath, " / "), theme.Group), sl.normalTitle) + sl.statusTitle()
+}
+
+// cycleOrder switches between config, alphabetical and frecency order.
+func (sl *SelectList) cycleOrder() {
+	sl.order = sl.order.next()
+	sl.normalItems = sl.sortedLevel()
+	sl.uiList.SelectedRow = 0
+	sl.refreshTitle()
+	if sl.selectedMode == SearchMode {
+		sl.doSearch()
+	}
+}
+
+// sortedLevel is the current level filtered by the picked tags, in the active order.
+func (sl *SelectList) sortedLevel() []Cmd {
+	return sortCommands(filterByTags(sl.levelItems, sl.tagFilter), sl.order, sl.usage)
+}
+
+// enterGroup shows the children of group and remembers the current level for leaveGroup.
+func (sl *SelectList) enterGroup(group Cmd) {
+	sl.groupStack = append(sl.groupStack, groupLevel{
+		name:        group.Name,
+		items:       sl.levelItems,
+		selectedRow: sl.uiList.SelectedRow,
+	})
+	sl.levelItems = group.Children
+	sl.normalItems = sl.sortedLevel()
+	sl.uiList.SelectedRow = 0
+	sl.setNormalTitle()
+}
+
+// leaveGroup goes back to the parent group, restoring its selected row.
+func (sl *SelectList) leaveGroup() {
+	if len(sl.groupStack) == 0 {
+		return
+	}
+	parent := sl.groupStack[len(sl.groupStack)-1]
+	sl.groupStack = sl.groupStack[:len(sl.groupStack)-1]
+	sl.levelItems = parent.items
+	sl.normalItems = sl.sortedLevel()
+	sl.uiList.SelectedRow = parent.selectedRow
+	sl.setNormalTitle()
+}
+
+func (sl *SelectList) selectedItem() (Cmd, bool) {
+	var items []Cmd
+	if sl.selectedMode == NormalMode {
+		items = sl.normalItems
+	} else if sl.selectedMode == SearchMode {
+		items = sl.searchItems
+	}
+	if sl.uiList.SelectedRow < 0 || sl.uiList.SelectedRow >= len(items) {
+		return Cmd{}, false
+	}
+	return items[sl.uiList.SelectedRow], true
 }
 
 func (sl *SelectList) doSearch() {
-	var searchResult []Cmd
-	for _, v := range sl.normalItems {
-		if fuzzy.Match(sl.searchStr, v.Name) || fuzzy.Match(sl.searchStr, v.Cmd) {
-			searchResult = append(searchResult, v)
+	sl.uiList.SelectedRow = 0
+	candidates := sortCommands(filterByTags(sl.allItems, sl.tagFilter), sl.order, sl.usage)
+	sl.searchItems = searchCommands(candidates, sl.searchStr, sl.matcher())
+}
+
+// submit sends cmd to selectedCommandChan, asking for the values of its placeholders first.
+func (sl *SelectList) submit(cmd Cmd) {
+	if placeholders := parsePlaceholders(cmd.Script()); len(placeholders) > 0 {
+		sl.startPrompt(cmd, placeholders)
+		return
+	}
+	sl.close()
+	sl.selectedCommandChan <- cmd
+}
+
+// markIndex returns the position of cmd in the marked commands, -1 when it is not marked.
+func (sl *SelectList) markIndex(cmd Cmd) int {
+	for i, marked := range sl.marked {
+		if marked.Source == cmd.Source && marked.FullName() == cmd.FullName() {
+			return i
 		}
 	}
-	sl.uiList.SelectedRow = 0
-	sl.searchItems = searchResult
+	return -1
+}
+
+// toggleMark marks or unmarks the selected command, then moves to the next row.
+func (sl *SelectList) toggleMark() {
+	selectedCmd, ok := sl.selectedItem()
+	if !ok || selectedCmd.IsGroup() {
+		return
+	}
+	if i := sl.markIndex(selectedCmd); i >= 0 {
+		sl.marked = append(sl.marked[:i:i], sl.marked[i+1:]...)
+	} else {
+		sl.marked = append(sl.marked, selectedCmd)
+	}
+	sl.uiList.ScrollDown()
+	sl.refreshTitle()
+}
+
+func (sl *SelectList) clearMarks() {
+	sl.marked = nil
+	sl.refreshTitle()
+}
+
+func (sl *SelectList) refreshTitle() {
+	if sl.selectedMode == SearchMode {
+		sl.setSearchTitle()
+	} else {
+		sl.setNormalTitle()
+	}
+}
+
+// submitMarked sends the marked commands as one batch, in the order they were marked.
+// The marks are kept until the list is closed, so that a cancelled prompt does not lose them.
+func (sl *SelectList) submitMarked(parallel bool) {
+	if len(sl.marked) == 0 {
+		return
+	}
+	sl.submit(newBatch(sl.marked, parallel))
 }
 
 func (sl *SelectList) close() {
@@ -238,27 +610,93 @@ func (sl *SelectList) close() {
 	}
 
 	sl.isClose = true
+	sl.marked = nil
 	ui.Close()
 }
 
+// Reopen shows the list again after a command returned, keeping the selection and the search string.
+func (sl *SelectList) Reopen() {
+	if !sl.isClose {
+		return
+	}
+	if err := ui.Init(); err != nil {
+		color.Red("Failed to initialize termui: %v", err)
+		os.Exit(1)
+	}
+	sl.isClose = false
+	sl.loadLastRuns()
+	sl.reloadUsage()
+	if sl.selectedMode != PromptMode && sl.selectedMode != HistoryMode {
+		sl.refreshTitle()
+	}
+	sl.resizeUI()
+	sl.renderUI()
+}
+
+// reloadUsage reads the usage store again after commands ran and sorts the list with it,
+// keeping the selected command selected.
+func (sl *SelectList) reloadUsage() {
+	selected, ok := sl.selectedItem()
+	sl.usage = loadUsage()
+	sl.normalItems = sl.sortedLevel()
+	items := sl.normalItems
+	if sl.selectedMode == SearchMode {
+		sl.doSearch()
+		items = sl.searchItems
+	}
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		if usageKey(item) == usageKey(selected) && item.Source == selected.Source {
+			sl.uiList.SelectedRow = i
+			return
+		}
+	}
+}
+
+// listed tells whether cmd is a command of the config, unlike the rsync commands built by the list.
+func (sl *SelectList) listed(cmd Cmd) bool {
+	for _, item := range sl.allItems {
+		if usageKey(item) == usageKey(cmd) && item.Source == cmd.Source {
+			return true
+		}
+	}
+	return false
+}
+
 func (sl *SelectList) rsync() {
-	selectedCmd := Cmd{}
-	if sl.selectedMode == NormalMode {
-		selectedCmd = sl.normalItems[sl.uiList.SelectedRow]
-	} else if sl.selectedMode == SearchMode {
-		selectedCmd = sl.searchItems[sl.uiList.SelectedRow]
+	selectedCmd, ok := sl.selectedItem()
+	if !ok {
+		return
 	}
 
 	uploadCmd, err := sl.rsyncUploader.Upload(selectedCmd)
-	if errors.Is(err, ErrRsUserCancel) || errors.Is(err, ErrRsNotSSHCmd) {
-		debug("RsyncUpload get: %+v, then do nothing.", err)
+	if errors.Is(err, ErrRsNoFileChooser) {
+		sl.enterFiles(selectedCmd)
+		return
+	}
+	sl.sendRsync(fmt.Sprintf("Rsync %s", selectedCmd.Name), uploadCmd, err)
+}
+
+// sendRsync asks to confirm the rsync command built for a transfer, the errors of a cancelled or unsupported one
+// are ignored.
+func (sl *SelectList) sendRsync(name string, rsyncCmd string, err error) {
+	if errors.Is(err, ErrRsUserCancel) || errors.Is(err, ErrRsNotSSHCmd) || errors.Is(err, ErrRsNoRemotePath) {
+		debug("Rsync get: %+v, then do nothing.", err)
 		return
 	} else if err != nil {
 		sl.close()
-		color.Red("RsyncUpload get: %v, will exit.", err)
+		color.Red("Rsync get: %v, will exit.", err)
 		os.Exit(1)
 	}
 
-	sl.close()
-	sl.selectedCommandChan <- Cmd{uploadCmd, fmt.Sprintf("Rsync %s", selectedCmd.Name), ""}
+	sl.confirmRsync(name, rsyncCmd)
+}
+
+// download asks for the remote path and the local destination of the selected command, see startDownload.
+func (sl *SelectList) download() {
+	if selectedCmd, ok := sl.selectedItem(); ok && !selectedCmd.IsGroup() {
+		sl.startDownload(selectedCmd)
+	}
 }
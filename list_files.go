@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ui "github.com/fedomn/termui/v3"
+)
+
+// fileEntry is a file or a directory shown by the file picker.
+type fileEntry struct {
+	name string
+	dir  bool
+}
+
+// fileHit is an entry matching the filter of the file picker with its matched runes.
+type fileHit struct {
+	fileEntry
+	positions []int
+}
+
+// filePicker chooses the file to upload to the host of cmd when osascript is not available.
+type filePicker struct {
+	cmd        Cmd
+	returnMode listMode
+	dir        string
+	entries    []fileEntry
+	err        error
+	filter     string
+	showHidden bool
+	shown      []fileHit
+}
+
+// readDir lists dir with the directories first, a symlink to a directory counts as a directory.
+func readDir(dir string) ([]fileEntry, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fileEntry, 0, len(infos))
+	for _, info := range infos {
+		isDir := info.IsDir()
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(filepath.Join(dir, info.Name())); err == nil {
+				isDir = target.IsDir()
+			}
+		}
+		entries = append(entries, fileEntry{info.Name(), isDir})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].dir && !entries[j].dir
+	})
+	return entries, nil
+}
+
+// filterEntries drops the hidden entries unless showHidden, then keeps the ones fuzzy matching filter,
+// ignoring case, closer matches first.
+func filterEntries(entries []fileEntry, filter string, showHidden bool) []fileHit {
+	var hits []fileHit
+	var ranks []int
+	for _, entry := range entries {
+		if !showHidden && strings.HasPrefix(entry.name, ".") {
+			continue
+		}
+		if filter == "" {
+			hits = append(hits, fileHit{fileEntry: entry})
+			continue
+		}
+		if rank, positions, ok := (foldMatcher{}).Match(filter, entry.name); ok {
+			hits = append(hits, fileHit{entry, positions})
+			ranks = append(ranks, rank)
+		}
+	}
+	if filter != "" {
+		sort.Stable(fileHitsByRank{hits, ranks})
+	}
+	return hits
+}
+
+type fileHitsByRank struct {
+	hits  []fileHit
+	ranks []int
+}
+
+func (h fileHitsByRank) Len() int           { return len(h.hits) }
+func (h fileHitsByRank) Less(i, j int) bool { return h.ranks[i] < h.ranks[j] }
+func (h fileHitsByRank) Swap(i, j int) {
+	h.hits[i], h.hits[j] = h.hits[j], h.hits[i]
+	h.ranks[i], h.ranks[j] = h.ranks[j], h.ranks[i]
+}
+
+// enterFiles opens the file picker in the directory cmd runs in, or the working directory.
+func (sl *SelectList) enterFiles(cmd Cmd) {
+	dir := commandDir(cmd)
+	if dir == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			dir = cwd
+		} else {
+			dir = string(filepath.Separator)
+		}
+	}
+	sl.files = &filePicker{cmd: cmd, returnMode: sl.selectedMode}
+	sl.selectedMode = FileMode
+	sl.openDir(dir)
+}
+
+func (sl *SelectList) leaveFiles() {
+	sl.selectedMode = sl.files.returnMode
+	sl.files = nil
+	sl.uiList.SelectedRow = 0
+	sl.refreshTitle()
+}
+
+// openDir shows the entries of dir with an empty filter.
+func (sl *SelectList) openDir(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	sl.files.dir = dir
+	sl.files.entries, sl.files.err = readDir(dir)
+	sl.files.filter = ""
+	sl.uiList.SelectedRow = 0
+	sl.refreshFiles()
+}
+
+func (sl *SelectList) refreshFiles() {
+	sl.files.shown = filterEntries(sl.files.entries, sl.files.filter, sl.files.showHidden)
+	if sl.uiList.SelectedRow >= len(sl.files.shown) {
+		sl.uiList.SelectedRow = 0
+	}
+	sl.uiList.Title = fmt.Sprintf(sl.fileTitle, styled(sl.files.dir, theme.Info), styled(sl.files.filter, theme.Accent))
+	if sl.files.showHidden {
+		sl.uiList.Title += "  |  Hidden: " + styled("shown", theme.Label)
+	}
+}
+
+func (sl *SelectList) selectedFile() (fileHit, bool) {
+	if sl.uiList.SelectedRow >= len(sl.files.shown) {
+		return fileHit{}, false
+	}
+	return sl.files.shown[sl.uiList.SelectedRow], true
+}
+
+// pickFile builds the upload of path and leaves the file picker.
+func (sl *SelectList) pickFile(path string) {
+	cmd := sl.files.cmd
+	uploadCmd, err := sl.rsyncUploader.UploadFile(cmd, path)
+	sl.leaveFiles()
+	sl.sendRsync(fmt.Sprintf("Rsync %s", cmd.Name), uploadCmd, err)
+}
+
+func (sl *SelectList) fileRows() []string {
+	if sl.files.err != nil {
+		return []string{styled(sl.files.err.Error(), theme.Failure)}
+	}
+	var rows []string
+	for k, v := range sl.files.shown {
+		name, color := v.name, theme.Text
+		if v.dir {
+			name, color = name+"/", theme.Group
+		}
+		if k == sl.uiList.SelectedRow {
+			rows = append(rows, highlight(name, v.positions, style(color, "underline"), style(theme.Accent, "bold", "underline")))
+		} else {
+			rows = append(rows, highlight(name, v.positions, style(color), style(theme.Accent, "bold")))
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, styled("No files", theme.Warning))
+	}
+	return rows
+}
+
+func (sl *SelectList) handleEventsAtFileMode(e ui.Event) {
+	debug("File Mode Event: %+v", e)
+	if e.ID == "<Resize>" {
+		sl.resizeUI()
+		sl.renderUI()
+		return
+	}
+	switch sl.keymap.action(FileMode, e.ID) {
+	case actionScrollDown:
+		sl.uiList.ScrollDown()
+	case actionScrollUp:
+		sl.uiList.ScrollUp()
+	case actionSelect:
+		if entry, ok := sl.selectedFile(); ok && entry.dir {
+			sl.openDir(filepath.Join(sl.files.dir, entry.name))
+		} else if ok {
+			sl.pickFile(filepath.Join(sl.files.dir, entry.name))
+		}
+	case actionOpen:
+		if entry, ok := sl.selectedFile(); ok && entry.dir {
+			sl.openDir(filepath.Join(sl.files.dir, entry.name))
+		}
+	case actionPick:
+		if entry, ok := sl.selectedFile(); ok {
+			sl.pickFile(filepath.Join(sl.files.dir, entry.name))
+		}
+	case actionParent:
+		sl.openDir(filepath.Dir(sl.files.dir))
+	case actionHidden:
+		sl.files.showHidden = !sl.files.showHidden
+		sl.refreshFiles()
+	case actionErase:
+		sl.files.filter = ""
+		sl.refreshFiles()
+	case actionDeleteChar:
+		// an empty filter has nothing to delete, go up instead
+		if sl.files.filter == "" {
+			sl.openDir(filepath.Dir(sl.files.dir))
+		} else {
+			sl.files.filter = sl.files.filter[:len(sl.files.filter)-1]
+			sl.refreshFiles()
+		}
+	case actionBack:
+		sl.leaveFiles()
+	default:
+		// keys without an action are typed into the filter
+		if e.ID == "<Space>" {
+			sl.files.filter += " "
+		} else if len(e.ID) == 1 {
+			sl.files.filter += e.ID
+		} else {
+			return
+		}
+		sl.uiList.SelectedRow = 0
+		sl.refreshFiles()
+	}
+	sl.renderUI()
+}
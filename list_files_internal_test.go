@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fedomn/termui/v3/widgets"
+)
+
+func TestReadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-files")
+	Equals(t, "TempDir", nil, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"b.txt", ".env", "a.log"} {
+		Equals(t, "WriteFile "+name, nil, ioutil.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+	Equals(t, "Mkdir", nil, os.Mkdir(filepath.Join(dir, "logs"), 0700))
+	Equals(t, "Symlink", nil, os.Symlink(filepath.Join(dir, "logs"), filepath.Join(dir, "current")))
+
+	entries, err := readDir(dir)
+	Equals(t, "readDir error", nil, err)
+	wat := []fileEntry{{"current", true}, {"logs", true}, {".env", false}, {"a.log", false}, {"b.txt", false}}
+	Equals(t, "readDir", wat, entries)
+}
+
+func TestFilterEntries(t *testing.T) {
+	entries := []fileEntry{{"logs", true}, {".env", false}, {"Dump.sql", false}, {"deploy.log", false}}
+	var tests = []struct {
+		filter     string
+		showHidden bool
+		wat        []string
+	}{
+		{"", false, []string{"logs", "Dump.sql", "deploy.log"}},
+		{"", true, []string{"logs", ".env", "Dump.sql", "deploy.log"}},
+		{"dump", false, []string{"Dump.sql"}},
+		{"log", false, []string{"logs", "deploy.log"}},
+		{"env", false, nil},
+		{"env", true, []string{".env"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, hit := range filterEntries(entries, tt.filter, tt.showHidden) {
+			got = append(got, hit.name)
+		}
+		msg := fmt.Sprintf("filter: %q, showHidden: %v", tt.filter, tt.showHidden)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestEnterFilesDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-files")
+	Equals(t, "TempDir", nil, err)
+	defer os.RemoveAll(dir)
+	Equals(t, "Mkdir", nil, os.Mkdir(filepath.Join(dir, "web"), 0700))
+	Equals(t, "Setenv", nil, os.Setenv("C_FILES_DIR", dir))
+	defer os.Unsetenv("C_FILES_DIR")
+	cwd, err := os.Getwd()
+	Equals(t, "Getwd", nil, err)
+
+	var tests = []struct {
+		cmd Cmd
+		wat string
+	}{
+		{Cmd{}, cwd},
+		{Cmd{Dir: "web", Source: filepath.Join(dir, projectConfigName)}, filepath.Join(dir, "web")},
+		{Cmd{Dir: "$C_FILES_DIR/web"}, filepath.Join(dir, "web")},
+	}
+	for _, tt := range tests {
+		sl := &SelectList{uiList: widgets.NewList(), fileTitle: "%s %s"}
+		sl.enterFiles(tt.cmd)
+		Equals(t, fmt.Sprintf("cmd: %+v", tt.cmd), tt.wat, sl.files.dir)
+		Equals(t, fmt.Sprintf("read error of cmd: %+v", tt.cmd), nil, sl.files.err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	ui "github.com/fedomn/termui/v3"
+)
+
+func (sl *SelectList) enterHistory() {
+	entries, err := loadHistory(historyLimit)
+	if err != nil {
+		debug("Load history get: %v", err)
+	}
+	sl.historyItems = entries
+	sl.selectedMode = HistoryMode
+	sl.uiList.SelectedRow = 0
+	sl.uiList.Title = sl.historyTitle
+}
+
+func (sl *SelectList) leaveHistory() {
+	sl.historyItems = nil
+	sl.selectedMode = NormalMode
+	sl.uiList.SelectedRow = 0
+	sl.setNormalTitle()
+}
+
+func (sl *SelectList) historyRows() []string {
+	var rows []string
+	for k, v := range sl.historyItems {
+		status := "exec"
+		if v.ExitCode != nil && v.DurationMs != nil {
+			duration := time.Duration(*v.DurationMs) * time.Millisecond
+			status = fmt.Sprintf("exit %d, %v", *v.ExitCode, duration)
+		}
+		runAt := v.Time.Local().Format("2006-01-02 15:04")
+		if k == sl.uiList.SelectedRow {
+			rows = append(rows, fmt.Sprintf("%s %s %s %s %s %s", styled(fmt.Sprintf("[%02d]", k), theme.Selected),
+				styled(runAt, theme.Info), styled(v.Name, theme.Selected, "underline"), styled("("+status+")", theme.Warning),
+				styled("-", theme.Info, "bold"), styled(v.Cmd, theme.Selected, "bold")))
+		} else {
+			rows = append(rows, fmt.Sprintf("[%02d] %s %s (%s)", k, runAt, v.Name, status))
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, styled("No history", theme.Warning))
+	}
+	return rows
+}
+
+func (sl *SelectList) selectedHistory() (HistoryEntry, bool) {
+	if sl.uiList.SelectedRow < 0 || sl.uiList.SelectedRow >= len(sl.historyItems) {
+		return HistoryEntry{}, false
+	}
+	return sl.historyItems[sl.uiList.SelectedRow], true
+}
+
+// historyCommand is the command of the config entry was recorded for, so that its env, env_file, return
+// and source apply again, run from the recorded directory. The recorded script replaces the one of the
+// config when they differ, as for resolved placeholders, or when the command is no longer in the config.
+func (sl *SelectList) historyCommand(entry HistoryEntry) Cmd {
+	cmd := Cmd{Name: entry.Name, Path: entry.Path}
+	for _, item := range sl.allItems {
+		if usageKey(item) == usageKey(cmd) {
+			cmd = item
+			break
+		}
+	}
+	if cmd.Script() != entry.Cmd {
+		cmd.Cmd, cmd.Steps, cmd.Batch = entry.Cmd, nil, nil
+	}
+	cmd.Dir = entry.Cwd
+	return cmd
+}
+
+func (sl *SelectList) handleEventsAtHistoryMode(e ui.Event) {
+	debug("History Mode Event: %+v", e)
+	if e.ID == "<Resize>" {
+		sl.resizeUI()
+		sl.renderUI()
+		return
+	}
+	switch sl.keymap.action(HistoryMode, e.ID) {
+	case actionScrollDown:
+		sl.uiList.ScrollDown()
+	case actionScrollUp:
+		sl.uiList.ScrollUp()
+	case actionHalfPageDown:
+		sl.uiList.ScrollHalfPageDown()
+	case actionHalfPageUp:
+		sl.uiList.ScrollHalfPageUp()
+	case actionPageDown:
+		sl.uiList.ScrollPageDown()
+	case actionPageUp:
+		sl.uiList.ScrollPageUp()
+	case actionSelect:
+		if entry, ok := sl.selectedHistory(); ok {
+			sl.leaveHistory()
+			sl.close()
+			sl.selectedCommandChan <- sl.historyCommand(entry)
+		}
+	case actionBack:
+		sl.leaveHistory()
+	}
+	sl.renderUI()
+}
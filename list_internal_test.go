@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fedomn/termui/v3/widgets"
+)
+
+func TestEnterLeaveGroup(t *testing.T) {
+	prod := Cmd{Name: "prod", Cmd: "make prod", Path: []string{"web", "deploy"}}
+	deploy := Cmd{Name: "deploy", Path: []string{"web"}, Children: []Cmd{prod}}
+	build := Cmd{Name: "build", Cmd: "make", Path: []string{"web"}}
+	web := Cmd{Name: "web", Children: []Cmd{build, deploy}}
+	date := Cmd{Name: "date", Cmd: "date"}
+	root := []Cmd{date, web}
+	sl := &SelectList{levelItems: root, normalItems: root, uiList: widgets.NewList(), normalTitle: "Usage"}
+	sl.uiList.SelectedRow = 1
+
+	status := sl.statusTitle()
+	sl.enterGroup(web)
+	Equals(t, "items of web", []Cmd{build, deploy}, sl.normalItems)
+	Equals(t, "row in web", 0, sl.uiList.SelectedRow)
+	Equals(t, "title in web", "Group: [web](fg:yellow)  |  Usage"+status, sl.uiList.Title)
+
+	sl.uiList.SelectedRow = 1
+	sl.enterGroup(deploy)
+	Equals(t, "items of deploy", []Cmd{prod}, sl.normalItems)
+	Equals(t, "title in deploy", "Group: [web / deploy](fg:yellow)  |  Usage"+status, sl.uiList.Title)
+
+	sl.leaveGroup()
+	Equals(t, "items back in web", []Cmd{build, deploy}, sl.normalItems)
+	Equals(t, "row back in web", 1, sl.uiList.SelectedRow)
+	Equals(t, "title back in web", "Group: [web](fg:yellow)  |  Usage"+status, sl.uiList.Title)
+
+	sl.leaveGroup()
+	Equals(t, "items back at root", root, sl.normalItems)
+	Equals(t, "row back at root", 1, sl.uiList.SelectedRow)
+	Equals(t, "title back at root", "Usage"+status, sl.uiList.Title)
+
+	sl.leaveGroup()
+	Equals(t, "leave at root keeps the items", root, sl.normalItems)
+	Equals(t, "leave at root keeps the row", 1, sl.uiList.SelectedRow)
+}
+
+func TestSelectedHistory(t *testing.T) {
+	entries := []HistoryEntry{{Name: "date", Cmd: "date"}}
+	var tests = []struct {
+		items []HistoryEntry
+		row   int
+		wat   bool
+	}{
+		{nil, 0, false},
+		{nil, -1, false},
+		{entries, -1, false},
+		{entries, 0, true},
+		{entries, 1, false},
+	}
+	for _, tt := range tests {
+		sl := &SelectList{historyItems: tt.items, uiList: widgets.NewList()}
+		sl.uiList.SelectedRow = tt.row
+		_, ok := sl.selectedHistory()
+		Equals(t, fmt.Sprintf("items: %v, row: %d", tt.items, tt.row), tt.wat, ok)
+	}
+	sl := &SelectList{uiList: widgets.NewList()}
+	Equals(t, "empty history rows", []string{"[No history](fg:yellow)"}, sl.historyRows())
+}
+
+func TestHistoryCommand(t *testing.T) {
+	deploy := Cmd{Name: "deploy", Path: []string{"web"}, Cmd: "make deploy", Dir: "web", Env: map[string]string{"APP_ENV": "prod"},
+		EnvFile: ".env", Return: true, Source: "/src/app/.c.yaml"}
+	pipeline := Cmd{Name: "check", Steps: []Step{{Cmd: "make"}, {Cmd: "make test"}}}
+	sl := &SelectList{allItems: []Cmd{deploy, pipeline, {Name: "deploy", Cmd: "other"}}}
+
+	var tests = []struct {
+		entry HistoryEntry
+		wat   Cmd
+	}{
+		{HistoryEntry{Name: "deploy", Path: []string{"web"}, Cmd: "make deploy", Cwd: "/src/app/web"},
+			Cmd{Name: "deploy", Path: []string{"web"}, Cmd: "make deploy", Dir: "/src/app/web", Env: map[string]string{"APP_ENV": "prod"},
+				EnvFile: ".env", Return: true, Source: "/src/app/.c.yaml"}},
+		{HistoryEntry{Name: "deploy", Path: []string{"web"}, Cmd: "make deploy HOST=web1", Cwd: "/src/app/web"},
+			Cmd{Name: "deploy", Path: []string{"web"}, Cmd: "make deploy HOST=web1", Dir: "/src/app/web", Env: map[string]string{"APP_ENV": "prod"},
+				EnvFile: ".env", Return: true, Source: "/src/app/.c.yaml"}},
+		{HistoryEntry{Name: "check", Cmd: "(make) && (make test)", Cwd: "/tmp"},
+			Cmd{Name: "check", Steps: []Step{{Cmd: "make"}, {Cmd: "make test"}}, Dir: "/tmp"}},
+		{HistoryEntry{Name: "gone", Path: []string{"old"}, Cmd: "date", Cwd: "/tmp"},
+			Cmd{Name: "gone", Path: []string{"old"}, Cmd: "date", Dir: "/tmp"}},
+	}
+	for _, tt := range tests {
+		Equals(t, fmt.Sprintf("entry: %+v", tt.entry), tt.wat, sl.historyCommand(tt.entry))
+	}
+}
+
+func TestReloadUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-usage")
+	Equals(t, "create temp dir", nil, err)
+	defer os.RemoveAll(dir)
+
+	defaultConfigFile := configFile
+	configFile = filepath.Join(dir, ".c.conf")
+	defer func() { configFile = defaultConfigFile }()
+
+	items := annotatePaths([]Cmd{{Name: "build", Cmd: "make"}, {Name: "test", Cmd: "make test"}, {Name: "deploy", Cmd: "make deploy"}}, nil)
+	sl := &SelectList{levelItems: items, normalItems: items, allItems: items, uiList: widgets.NewList(),
+		order: FrecencyOrder, usage: loadUsage()}
+	sl.uiList.SelectedRow = 2
+
+	recordUsage(items[2])
+	recordUsage(items[2])
+	recordUsage(items[1])
+	sl.reloadUsage()
+	var got []string
+	for _, cmd := range sl.normalItems {
+		got = append(got, cmd.Name)
+	}
+	Equals(t, "frecency order after runs", []string{"deploy", "test", "build"}, got)
+	Equals(t, "selected command stays selected", 0, sl.uiList.SelectedRow)
+}
+
+func TestListed(t *testing.T) {
+	sl := &SelectList{allItems: flattenCommands(annotatePaths([]Cmd{
+		{Name: "web", Children: []Cmd{{Name: "deploy", Cmd: "make deploy"}}},
+		{Name: "app", Cmd: "make", Source: "/src/app/.c.yaml"},
+	}, nil))}
+
+	var tests = []struct {
+		cmd Cmd
+		wat bool
+	}{
+		{Cmd{Name: "deploy", Path: []string{"web"}, Cmd: "make deploy HOST=web1"}, true},
+		{Cmd{Name: "deploy", Cmd: "make deploy"}, false},
+		{Cmd{Name: "app", Cmd: "make", Source: "/src/app/.c.yaml"}, true},
+		{Cmd{Name: "app", Cmd: "make"}, false},
+		{Cmd{Name: "Rsync app", Cmd: "rsync -azP -e ssh -- /f user@ip:"}, false},
+	}
+	for _, tt := range tests {
+		Equals(t, fmt.Sprintf("cmd: %+v", tt.cmd), tt.wat, sl.listed(tt.cmd))
+	}
+}
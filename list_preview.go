@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPreviewWidth = 40
+	minPreviewWidth     = 20
+	maxPreviewWidth     = 80
+	previewWidthStep    = 5
+)
+
+// previewVisible reports whether the preview pane is shown beside the list, only commands have one.
+func (sl *SelectList) previewVisible() bool {
+	return sl.showPreview && (sl.selectedMode == NormalMode || sl.selectedMode == SearchMode)
+}
+
+func (sl *SelectList) togglePreview() {
+	sl.showPreview = !sl.showPreview
+	sl.layoutUI()
+}
+
+// resizePreview moves the split between the list and the preview by delta percent of the terminal width.
+func (sl *SelectList) resizePreview(delta int) {
+	width := sl.previewWidth + delta
+	if width < minPreviewWidth || width > maxPreviewWidth {
+		return
+	}
+	sl.previewWidth = width
+	sl.layoutUI()
+}
+
+// loadLastRuns indexes the most recent history entry of every command for the preview.
+func (sl *SelectList) loadLastRuns() {
+	entries, err := loadHistory(historyLimit)
+	if err != nil {
+		debug("Load history get: %v", err)
+	}
+	sl.lastRuns = make(map[string]HistoryEntry)
+	for _, entry := range entries {
+		key := usageKey(Cmd{Name: entry.Name, Path: entry.Path})
+		if _, ok := sl.lastRuns[key]; !ok {
+			sl.lastRuns[key] = entry
+		}
+	}
+}
+
+func (sl *SelectList) previewText() string {
+	cmd, ok := sl.selectedItem()
+	if !ok {
+		return styled("No command selected", theme.Warning)
+	}
+
+	var lines []string
+	field := func(name string, value string) {
+		lines = append(lines, fmt.Sprintf("%s %s", styled(name+":", theme.Info, "bold"), value))
+	}
+	field("Name", cmd.FullName())
+	if cmd.Description != "" {
+		field("Description", cmd.Description)
+	}
+	if cmd.IsGroup() {
+		field("Group", fmt.Sprintf("%d items", len(cmd.Children)))
+		for _, child := range cmd.Children {
+			lines = append(lines, "  "+child.Name)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if cmd.IsPipeline() {
+		field("Steps", "")
+		for i, step := range cmd.Steps {
+			line := fmt.Sprintf("  %d. %s: %s", i+1, step.displayName(i), styled(step.Cmd, theme.Selected))
+			if step.ContinueOnError {
+				line += " (continue on error)"
+			}
+			lines = append(lines, line)
+		}
+	} else {
+		field("Command", styled(cmd.Cmd, theme.Selected))
+	}
+	if cmd.Alias != "" {
+		field("Alias", cmd.Alias)
+	}
+	if cmd.Dir != "" {
+		field("Directory", cmd.Dir)
+	} else {
+		field("Directory", "working directory of c")
+	}
+	if len(cmd.Tags) > 0 {
+		field("Tags", strings.Join(cmd.Tags, ", "))
+	}
+	if cmd.Source != "" {
+		field("Source", cmd.Source)
+	} else {
+		field("Source", configFile)
+	}
+
+	entry, ok := sl.lastRuns[usageKey(cmd)]
+	switch {
+	case !ok:
+		field("Last run", "never")
+	case entry.ExitCode == nil:
+		field("Last run", entry.Time.Local().Format("2006-01-02 15:04"))
+	default:
+		duration := time.Duration(*entry.DurationMs) * time.Millisecond
+		status := styled(fmt.Sprintf("exit %d", *entry.ExitCode), theme.Success)
+		if *entry.ExitCode != 0 {
+			status = styled(fmt.Sprintf("exit %d", *entry.ExitCode), theme.Failure)
+		}
+		field("Last run", fmt.Sprintf("%s, %s, took %v", entry.Time.Local().Format("2006-01-02 15:04"), status, duration))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fedomn/termui/v3/widgets"
+)
+
+func TestPreviewText(t *testing.T) {
+	defaultConfigFile := configFile
+	configFile = "/etc/c/config.yaml"
+	defer func() { configFile = defaultConfigFile }()
+	exitCode, durationMs := 2, int64(1500)
+	runAt := time.Date(2020, 1, 2, 3, 4, 0, 0, time.Local)
+	sl := &SelectList{
+		normalItems: []Cmd{{Name: "deploy", Path: []string{"web"}, Description: "Ship it", Tags: []string{"prod", "web"},
+			Steps: []Step{{Name: "test", Cmd: "make test"}, {Cmd: "make push", ContinueOnError: true}}}},
+		uiList: widgets.NewList(),
+		lastRuns: map[string]HistoryEntry{
+			"web / deploy": {Time: runAt, Name: "deploy", ExitCode: &exitCode, DurationMs: &durationMs},
+		},
+	}
+	wat := []string{
+		"[Name:](fg:cyan,mod:bold) web/deploy",
+		"[Description:](fg:cyan,mod:bold) Ship it",
+		"[Steps:](fg:cyan,mod:bold) ",
+		"  1. test: [make test](fg:green)",
+		"  2. step 2: [make push](fg:green) (continue on error)",
+		"[Directory:](fg:cyan,mod:bold) working directory of c",
+		"[Tags:](fg:cyan,mod:bold) prod, web",
+		"[Source:](fg:cyan,mod:bold) /etc/c/config.yaml",
+		"[Last run:](fg:cyan,mod:bold) 2020-01-02 03:04, [exit 2](fg:red), took 1.5s",
+	}
+	Equals(t, "preview", wat, strings.Split(sl.previewText(), "\n"))
+}
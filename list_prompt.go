@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	ui "github.com/fedomn/termui/v3"
+)
+
+// promptKind tells what a prompt form asks for.
+type promptKind int
+
+const (
+	// placeholderPrompt asks for the placeholder values of a command.
+	placeholderPrompt promptKind = iota
+	// downloadPrompt asks for the paths of an rsync download from the command.
+	downloadPrompt
+	// rsyncPrompt shows the rsync command built for a transfer, it can be edited before it runs.
+	rsyncPrompt
+)
+
+// promptForm collects the placeholder values of a command before it is sent to selectedCommandChan.
+type promptForm struct {
+	kind         promptKind
+	cmd          Cmd
+	placeholders []Placeholder
+	values       []string
+	field        int
+	previousMode listMode
+	previousRow  int
+}
+
+func (pf *promptForm) valueMap() map[string]string {
+	values := make(map[string]string, len(pf.placeholders))
+	for i, placeholder := range pf.placeholders {
+		values[placeholder.Name] = pf.values[i]
+	}
+	return values
+}
+
+// downloadFields are the values asked by startDownload, the local destination defaults to the working directory.
+var downloadFields = []Placeholder{{Name: "remote path"}, {Name: "local destination", Default: "."}}
+
+func (sl *SelectList) startDownload(cmd Cmd) {
+	sl.startPrompt(cmd, downloadFields)
+	sl.prompt.kind = downloadPrompt
+	sl.uiList.Title = fmt.Sprintf(sl.promptTitle, styled("Download from "+cmd.Name, theme.Accent))
+}
+
+// confirmRsync shows the rsync command of a transfer before it is sent, the form is closed by sendRsync.
+func (sl *SelectList) confirmRsync(name string, rsyncCmd string) {
+	sl.startPrompt(Cmd{Name: name, Cmd: rsyncCmd}, []Placeholder{{Name: "rsync", Default: rsyncCmd}})
+	sl.prompt.kind = rsyncPrompt
+	sl.uiList.Title = fmt.Sprintf(sl.confirmTitle, styled(name, theme.Accent))
+}
+
+func (sl *SelectList) startPrompt(cmd Cmd, placeholders []Placeholder) {
+	values := make([]string, len(placeholders))
+	for i, placeholder := range placeholders {
+		values[i] = placeholder.Default
+	}
+	sl.prompt = &promptForm{
+		cmd:          cmd,
+		placeholders: placeholders,
+		values:       values,
+		previousMode: sl.selectedMode,
+		previousRow:  sl.uiList.SelectedRow,
+	}
+	sl.selectedMode = PromptMode
+	sl.uiList.SelectedRow = 0
+	sl.uiList.Title = fmt.Sprintf(sl.promptTitle, styled(cmd.Name, theme.Accent))
+}
+
+// leavePrompt restores the mode the prompt form was opened from.
+func (sl *SelectList) leavePrompt() {
+	sl.selectedMode = sl.prompt.previousMode
+	sl.uiList.SelectedRow = sl.prompt.previousRow
+	sl.prompt = nil
+	sl.refreshTitle()
+}
+
+func (sl *SelectList) confirmPrompt() {
+	switch sl.prompt.kind {
+	case downloadPrompt:
+		sl.confirmDownload()
+		return
+	case rsyncPrompt:
+		sl.runRsync()
+		return
+	}
+	resolvedCmd := resolveCommand(sl.prompt.cmd, sl.prompt.valueMap())
+	sl.leavePrompt()
+	sl.close()
+	sl.selectedCommandChan <- resolvedCmd
+}
+
+// confirmDownload builds the rsync download, the form stays open until the remote path is filled in.
+func (sl *SelectList) confirmDownload() {
+	cmd, remotePath, localPath := sl.prompt.cmd, sl.prompt.values[0], sl.prompt.values[1]
+	if remotePath == "" {
+		sl.prompt.field = 0
+		sl.renderUI()
+		return
+	}
+	downloadCmd, err := sl.rsyncUploader.Download(cmd, remotePath, localPath)
+	sl.leavePrompt()
+	sl.sendRsync(fmt.Sprintf("Rsync download %s", cmd.Name), downloadCmd, err)
+	sl.renderUI()
+}
+
+// runRsync sends the rsync command as it was confirmed, possibly edited.
+func (sl *SelectList) runRsync() {
+	rsyncCmd := Cmd{Name: sl.prompt.cmd.Name, Cmd: strings.TrimSpace(sl.prompt.values[0])}
+	if rsyncCmd.Cmd == "" {
+		return
+	}
+	sl.leavePrompt()
+	sl.close()
+	sl.selectedCommandChan <- rsyncCmd
+}
+
+func (sl *SelectList) promptRows() []string {
+	var rows []string
+	for k, placeholder := range sl.prompt.placeholders {
+		if k == sl.prompt.field {
+			rows = append(rows, fmt.Sprintf("%s %s %s", styled(placeholder.Name, theme.Selected, "underline"),
+				styled("=", theme.Info, "bold"), styled(sl.prompt.values[k]+"_", theme.Selected, "bold")))
+		} else {
+			rows = append(rows, fmt.Sprintf("%s = %s", placeholder.Name, sl.prompt.values[k]))
+		}
+	}
+	preview := resolveCommand(sl.prompt.cmd, sl.prompt.valueMap()).Script()
+	switch sl.prompt.kind {
+	case downloadPrompt:
+		preview = fmt.Sprintf("rsync from %s:%s to %s", sl.prompt.cmd.Name, sl.prompt.values[0], sl.prompt.values[1])
+	case rsyncPrompt:
+		// the field is the command itself
+		return rows
+	}
+	rows = append(rows, "", fmt.Sprintf("%s %s", styled("Preview:", theme.Info), preview))
+	return rows
+}
+
+func (sl *SelectList) handleEventsAtPromptMode(e ui.Event) {
+	debug("Prompt Mode Event: %+v", e)
+	pf := sl.prompt
+	switch e.ID {
+	case "<Down>", "<C-j>", "<Tab>":
+		if pf.field < len(pf.placeholders)-1 {
+			pf.field++
+		}
+	case "<Up>", "<C-k>":
+		if pf.field > 0 {
+			pf.field--
+		}
+	case "<Enter>":
+		if pf.field < len(pf.placeholders)-1 {
+			pf.field++
+		} else {
+			sl.confirmPrompt()
+			return
+		}
+	case "<C-u>":
+		pf.values[pf.field] = ""
+	case "<Backspace>":
+		if value := pf.values[pf.field]; len(value) > 0 {
+			pf.values[pf.field] = value[:len(value)-1]
+		}
+	case "<Space>":
+		pf.values[pf.field] += " "
+	case "<C-c>", "<Escape>":
+		sl.leavePrompt()
+	case "<Resize>":
+		sl.resizeUI()
+	default:
+		if len(e.ID) != 1 {
+			return
+		}
+		pf.values[pf.field] += e.ID
+	}
+	sl.renderUI()
+}
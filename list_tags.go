@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	ui "github.com/fedomn/termui/v3"
+)
+
+const tagPanelWidth = 25
+
+// enterTags opens the tag panel beside the list, picked tags filter the list while it is open.
+func (sl *SelectList) enterTags() {
+	sl.tags = collectTags(sl.allItems)
+	sl.selectedMode = TagMode
+	sl.tagList.SelectedRow = 0
+	sl.uiList.Title = sl.tagTitle + sl.statusTitle()
+}
+
+func (sl *SelectList) leaveTags() {
+	sl.selectedMode = NormalMode
+	sl.setNormalTitle()
+}
+
+func (sl *SelectList) tagPicked(tag string) bool {
+	for _, picked := range sl.tagFilter {
+		if picked == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleTag picks or drops the selected tag. The list goes back to the top level,
+// the groups it was showing may not contain any command with the new tags.
+func (sl *SelectList) toggleTag() {
+	if sl.tagList.SelectedRow >= len(sl.tags) {
+		return
+	}
+	tag := sl.tags[sl.tagList.SelectedRow].tag
+	if sl.tagPicked(tag) {
+		var tags []string
+		for _, picked := range sl.tagFilter {
+			if picked != tag {
+				tags = append(tags, picked)
+			}
+		}
+		sl.tagFilter = tags
+	} else {
+		sl.tagFilter = append(sl.tagFilter, tag)
+	}
+	sl.applyTagFilter()
+}
+
+func (sl *SelectList) clearTags() {
+	sl.tagFilter = nil
+	sl.applyTagFilter()
+}
+
+func (sl *SelectList) applyTagFilter() {
+	if len(sl.groupStack) > 0 {
+		sl.levelItems = sl.groupStack[0].items
+		sl.groupStack = nil
+	}
+	sl.normalItems = sl.sortedLevel()
+	sl.uiList.SelectedRow = 0
+	sl.uiList.Title = sl.tagTitle + sl.statusTitle()
+}
+
+func (sl *SelectList) tagRows() []string {
+	var rows []string
+	for k, v := range sl.tags {
+		check := "[ ]"
+		if sl.tagPicked(v.tag) {
+			check = styled("[x]", theme.Selected)
+		}
+		if k == sl.tagList.SelectedRow {
+			rows = append(rows, fmt.Sprintf("%s %s (%d)", check, styled(v.tag, theme.Selected, "underline"), v.count))
+		} else {
+			rows = append(rows, fmt.Sprintf("%s %s (%d)", check, v.tag, v.count))
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, styled("No tags in the config", theme.Warning))
+	}
+	return rows
+}
+
+func (sl *SelectList) tagFilterTitle() string {
+	if len(sl.tagFilter) == 0 {
+		return ""
+	}
+	return "  |  Tags: " + styled(strings.Join(sl.tagFilter, ", "), theme.Info)
+}
+
+func (sl *SelectList) handleEventsAtTagMode(e ui.Event) {
+	debug("Tag Mode Event: %+v", e)
+	if e.ID == "<Resize>" {
+		sl.resizeUI()
+		sl.renderUI()
+		return
+	}
+	switch sl.keymap.action(TagMode, e.ID) {
+	case actionScrollDown:
+		sl.tagList.ScrollDown()
+	case actionScrollUp:
+		sl.tagList.ScrollUp()
+	case actionPick:
+		sl.toggleTag()
+	case actionClear:
+		sl.clearTags()
+	case actionBack:
+		sl.leaveTags()
+	}
+	sl.renderUI()
+}
@@ -202,6 +202,9 @@ var _ = Describe("Select List", func() {
 
 			Expect(selectList.uiList.SelectedRow).To(Equal(0))
 			pressKeyWithCtrl(keybd.VK_R)
+			Expect(selectList.selectedMode).To(Equal(PromptMode))
+			Expect(selectList.uiList.Title).To(HavePrefix("Confirm:"))
+			pressKey(keybd.VK_ENTER)
 
 			Expect(<-cmdChan).To(Equal(Cmd{Name: "Rsync normal_cmd1_name", Cmd: `rsync fake`}))
 			Expect(selectList.isClose).To(BeTrue())
@@ -225,6 +228,105 @@ var _ = Describe("Select List", func() {
 
 			close(done)
 		})
+
+		It("should send rsync download cmd to chan by shortcut <C-g>", func(done Done) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go selectList.listenEventsWithCancel(ctx)
+
+			mockRsyncUploader.EXPECT().Download(cmds[0], "log", ".").Return("rsync fake", nil)
+
+			pressKeyWithCtrl(keybd.VK_G)
+			Expect(selectList.selectedMode).To(Equal(PromptMode))
+			Expect(selectList.uiList.Title).To(HavePrefix("Fill in:"))
+
+			// the remote path is required
+			pressKey(keybd.VK_ENTER, keybd.VK_ENTER)
+			Expect(selectList.prompt.field).To(Equal(0))
+
+			pressKey(keybd.VK_L, keybd.VK_O, keybd.VK_G, keybd.VK_ENTER, keybd.VK_ENTER)
+			Expect(selectList.uiList.Title).To(HavePrefix("Confirm:"))
+			pressKey(keybd.VK_ENTER)
+			Expect(<-cmdChan).To(Equal(Cmd{Name: "Rsync download normal_cmd1_name", Cmd: `rsync fake`}))
+			Expect(selectList.isClose).To(BeTrue())
+
+			close(done)
+		})
+
+		It("should open the file picker without osascript by shortcut <C-r>", func(done Done) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go selectList.listenEventsWithCancel(ctx)
+
+			mockRsyncUploader.EXPECT().Upload(cmds[0]).Return("", ErrRsNoFileChooser)
+
+			pressKeyWithCtrl(keybd.VK_R)
+			Expect(selectList.selectedMode).To(Equal(FileMode))
+			Expect(selectList.uiList.Title).To(HavePrefix("Upload:"))
+			Expect(selectList.isClose).To(BeFalse())
+
+			pressKey(keybd.VK_ESC)
+			Expect(selectList.selectedMode).To(Equal(NormalMode))
+			Expect(selectList.uiList.Title).To(HavePrefix("Usage:"))
+
+			close(done)
+		})
+	})
+
+	Context("Groups", func() {
+		BeforeEach(func() {
+			selectList.close()
+			cmds = []Cmd{
+				{Name: "normal_cmd1_name", Cmd: `echo normal_cmd1_name`},
+				{Name: "web", Children: []Cmd{
+					{Name: "build", Cmd: `echo build`},
+					{Name: "deploy", Children: []Cmd{{Name: "prod", Cmd: `echo prod`}}},
+				}},
+			}
+			selectList = NewUIList(cmds, cmdChan)
+			selectList.registerRsyncUploader(mockRsyncUploader)
+		})
+
+		It("should drill into groups by <Enter> and l, and go back by h and <Backspace>", func(done Done) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go selectList.listenEventsWithCancel(ctx)
+
+			Expect(selectList.uiList.Title).To(HavePrefix("Usage:"))
+
+			pressKey(keybd.VK_J, keybd.VK_ENTER)
+			Expect(selectList.selectedMode).To(Equal(NormalMode))
+			Expect(selectList.uiList.Title).To(HavePrefix("Group: [web]"))
+			Expect(selectList.uiList.Rows).To(HaveLen(2))
+			Expect(selectList.uiList.SelectedRow).To(Equal(0))
+
+			pressKey(keybd.VK_J, keybd.VK_L)
+			Expect(selectList.uiList.Title).To(HavePrefix("Group: [web / deploy]"))
+			Expect(selectList.uiList.Rows).To(HaveLen(1))
+
+			pressKey(keybd.VK_H)
+			Expect(selectList.uiList.Title).To(HavePrefix("Group: [web]"))
+			Expect(selectList.uiList.SelectedRow).To(Equal(1))
+
+			pressKey(keybd.VK_BACKSPACE)
+			Expect(selectList.uiList.Title).To(HavePrefix("Usage:"))
+			Expect(selectList.uiList.Rows).To(HaveLen(2))
+			Expect(selectList.uiList.SelectedRow).To(Equal(1))
+
+			close(done)
+		})
+
+		It("should send a cmd of a group to chan by <Enter>", func(done Done) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go selectList.listenEventsWithCancel(ctx)
+
+			pressKey(keybd.VK_J, keybd.VK_L, keybd.VK_ENTER)
+
+			Expect((<-cmdChan).Name).To(Equal("build"))
+			Expect(selectList.isClose).To(BeTrue())
+			close(done)
+		})
 	})
 
 	Context("Search Mode", func() {
@@ -299,10 +401,10 @@ var _ = Describe("Select List", func() {
 			Expect(selectList.uiList.Rows).To(HaveLen(2))
 			Expect(selectList.uiList.SelectedRow).To(Equal(0))
 
-			// press <Space> will search nothing
+			// press <Space> starts a new search term, "search_cmd" still filters
 			pressKey(keybd.VK_SPACE)
 			Expect(selectList.uiList.Title).To(ContainSubstring("search_cmd "))
-			Expect(selectList.uiList.Rows).To(HaveLen(0))
+			Expect(selectList.uiList.Rows).To(HaveLen(2))
 			Expect(selectList.uiList.SelectedRow).To(Equal(0))
 
 			// press <C-u> to erase search string
@@ -368,6 +470,8 @@ var _ = Describe("Select List", func() {
 
 			mockRsyncUploader.EXPECT().Upload(cmds[0]).Return("rsync fake", nil)
 			pressKeyWithCtrl(keybd.VK_R)
+			Expect(selectList.uiList.Title).To(HavePrefix("Confirm:"))
+			pressKey(keybd.VK_ENTER)
 
 			Expect(<-cmdChan).To(Equal(Cmd{Name: "Rsync normal_cmd1_name", Cmd: `rsync fake`}))
 			Expect(selectList.isClose).To(BeTrue())
@@ -1,13 +1,82 @@
 package main
 
+import (
+	"flag"
+	"os"
+
+	"github.com/fatih/color"
+)
+
 func main() {
+	opts, err := parseArgs(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		os.Exit(2)
+	}
+
+	if noColor() {
+		color.NoColor = true
+	}
+	configFile, configSource = resolveConfigFile(opts.config)
+	if opts.print {
+		// stdout only carries the selected command, messages go to stderr
+		color.Output = os.Stderr
+	}
+	if !opts.interactive() {
+		os.Exit(runCLI(opts))
+	}
+
+	config := LoadConfig()
+	if theme, err = newTheme(config.Theme); err != nil {
+		color.Red("Failed to load the theme of %s: %v", configFile, err)
+		os.Exit(1)
+	}
+	if config.Theme.Preset == "monochrome" {
+		color.NoColor = true
+	}
+
 	selectedCommandChan := make(chan Cmd)
-	uiList := NewUIList(LoadCommands(), selectedCommandChan)
+	uiList := NewUIList(config.Commands, selectedCommandChan)
+	km, err := newKeymap(config.Keymap)
+	if err != nil {
+		uiList.close()
+		color.Red("Failed to load the keymap of %s: %v", configFile, err)
+		os.Exit(1)
+	}
+	uiList.setKeymap(km)
 	uiList.registerRsyncUploader(RsyncPlugin{})
 
 	go uiList.ListenEvents()
 
 	for command := range selectedCommandChan {
+		if command.IsBatch() {
+			for _, member := range command.Batch {
+				if uiList.listed(member) {
+					recordUsage(member)
+				}
+			}
+		} else if command.Runnable() && uiList.listed(command) {
+			recordUsage(command)
+		}
+		if opts.print {
+			PrintCommand(command)
+		}
+		if command.IsBatch() {
+			result := RunBatch(command)
+			if !command.Return && !config.Return {
+				os.Exit(result.ExitCode)
+			}
+			WaitForReturn()
+			uiList.Reopen()
+			continue
+		}
+		if command.Return || config.Return {
+			RunCommand(command)
+			WaitForReturn()
+			uiList.Reopen()
+			continue
+		}
 		ExecCommand(command)
 	}
 }
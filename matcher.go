@@ -0,0 +1,128 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"golang.org/x/text/unicode/norm"
+)
+
+// matcher decides whether a search query matches a text. rank orders the matches, lower is better,
+// and positions are the indexes of the matched runes of text to highlight.
+type matcher interface {
+	Name() string
+	Match(query string, text string) (rank int, positions []int, ok bool)
+}
+
+// matchers are cycled in search mode, the first one is the default.
+var matchers = []matcher{fuzzyMatcher{}, foldMatcher{}, exactMatcher{}, prefixMatcher{}, &regexMatcher{}}
+
+// fuzzyMatcher matches the runes of the query in order, not necessarily next to each other.
+type fuzzyMatcher struct{}
+
+func (fuzzyMatcher) Name() string { return "fuzzy" }
+
+func (fuzzyMatcher) Match(query string, text string) (int, []int, bool) {
+	rank := fuzzy.RankMatch(query, text)
+	if rank < 0 {
+		return 0, nil, false
+	}
+	return rank, matchPositions([]rune(query), []rune(text)), true
+}
+
+// foldMatcher is fuzzyMatcher ignoring case and diacritics, "cafe" matches "Café".
+type foldMatcher struct{}
+
+func (foldMatcher) Name() string { return "fuzzy-fold" }
+
+func (foldMatcher) Match(query string, text string) (int, []int, bool) {
+	rank := fuzzy.RankMatchNormalizedFold(query, text)
+	if rank < 0 {
+		return 0, nil, false
+	}
+	return rank, matchPositions(foldRunes(query), foldRunes(text)), true
+}
+
+// foldRunes lower-cases every rune and drops its diacritics, keeping one rune per rune of s.
+func foldRunes(s string) []rune {
+	folded := []rune(s)
+	for i, r := range folded {
+		base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(r)))
+		folded[i] = unicode.ToLower(base)
+	}
+	return folded
+}
+
+// exactMatcher matches the query as a substring, earlier occurrences rank first.
+type exactMatcher struct{}
+
+func (exactMatcher) Name() string { return "exact" }
+
+func (exactMatcher) Match(query string, text string) (int, []int, bool) {
+	index := strings.Index(text, query)
+	if index < 0 {
+		return 0, nil, false
+	}
+	start := utf8.RuneCountInString(text[:index])
+	return start, runeRange(start, utf8.RuneCountInString(query)), true
+}
+
+// prefixMatcher matches the query at the beginning of a word, earlier words rank first.
+type prefixMatcher struct{}
+
+func (prefixMatcher) Name() string { return "prefix" }
+
+func (prefixMatcher) Match(query string, text string) (int, []int, bool) {
+	queryRunes, textRunes := []rune(query), []rune(text)
+	word := 0
+	for i := range textRunes {
+		if !isWordRune(textRunes[i]) || (i > 0 && isWordRune(textRunes[i-1])) {
+			continue
+		}
+		if strings.HasPrefix(string(textRunes[i:]), query) {
+			return word, runeRange(i, len(queryRunes)), true
+		}
+		word++
+	}
+	return 0, nil, false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// regexMatcher matches the query as a regular expression, an invalid one matches nothing.
+type regexMatcher struct {
+	query string
+	re    *regexp.Regexp
+}
+
+func (*regexMatcher) Name() string { return "regex" }
+
+func (m *regexMatcher) Match(query string, text string) (int, []int, bool) {
+	// the same query is matched against every command, compile it once
+	if m.re == nil || m.query != query {
+		m.query = query
+		m.re, _ = regexp.Compile(query)
+	}
+	if m.re == nil {
+		return 0, nil, false
+	}
+	loc := m.re.FindStringIndex(text)
+	if loc == nil {
+		return 0, nil, false
+	}
+	start := utf8.RuneCountInString(text[:loc[0]])
+	return start, runeRange(start, utf8.RuneCountInString(text[loc[0]:loc[1]])), true
+}
+
+func runeRange(start int, length int) []int {
+	positions := make([]int, length)
+	for i := range positions {
+		positions[i] = start + i
+	}
+	return positions
+}
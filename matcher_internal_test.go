@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	var tests = []struct {
+		m         matcher
+		query     string
+		text      string
+		ok        bool
+		rank      int
+		positions []int
+	}{
+		{fuzzyMatcher{}, "db", "dump db", true, 5, []int{0, 6}},
+		{fuzzyMatcher{}, "DB", "dump db", false, 0, nil},
+		{foldMatcher{}, "cafe", "Open Café", true, 5, []int{5, 6, 7, 8}},
+		{exactMatcher{}, "db", "dump db", true, 5, []int{5, 6}},
+		{exactMatcher{}, "dp", "dump db", false, 0, nil},
+		{prefixMatcher{}, "db", "dump db-1", true, 1, []int{5, 6}},
+		{prefixMatcher{}, "ump", "dump db", false, 0, nil},
+		{&regexMatcher{}, "d[a-z]+p", "sudo dump", true, 5, []int{5, 6, 7, 8}},
+		{&regexMatcher{}, "d[", "sudo dump", false, 0, nil},
+	}
+	for _, tt := range tests {
+		rank, positions, ok := tt.m.Match(tt.query, tt.text)
+		msg := fmt.Sprintf("matcher: %s, query: %s, text: %s", tt.m.Name(), tt.query, tt.text)
+		Equals(t, msg, tt.ok, ok)
+		Equals(t, msg, tt.rank, rank)
+		Equals(t, msg, tt.positions, positions)
+	}
+}
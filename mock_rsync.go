@@ -49,3 +49,33 @@ func (mr *MockRsyncUploaderMockRecorder) Upload(cmd interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockRsyncUploader)(nil).Upload), cmd)
 }
+
+// UploadFile mocks base method
+func (m *MockRsyncUploader) UploadFile(cmd Cmd, path string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadFile", cmd, path)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadFile indicates an expected call of UploadFile
+func (mr *MockRsyncUploaderMockRecorder) UploadFile(cmd, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockRsyncUploader)(nil).UploadFile), cmd, path)
+}
+
+// Download mocks base method
+func (m *MockRsyncUploader) Download(cmd Cmd, remotePath, localPath string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Download", cmd, remotePath, localPath)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Download indicates an expected call of Download
+func (mr *MockRsyncUploaderMockRecorder) Download(cmd, remotePath, localPath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockRsyncUploader)(nil).Download), cmd, remotePath, localPath)
+}
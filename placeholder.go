@@ -0,0 +1,70 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches {{name}} and {{name:default}} in a command.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_-]*)\s*(?::([^}]*))?\}\}`)
+
+type Placeholder struct {
+	Name    string
+	Default string
+}
+
+// parsePlaceholders returns the placeholders of cmdStr in order of first appearance.
+// A placeholder used several times is returned once, with the first default given for it.
+func parsePlaceholders(cmdStr string) []Placeholder {
+	var placeholders []Placeholder
+	seen := make(map[string]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(cmdStr, -1) {
+		name, defaultValue := match[1], strings.TrimSpace(match[2])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		placeholders = append(placeholders, Placeholder{Name: name, Default: defaultValue})
+	}
+	return placeholders
+}
+
+// resolvePlaceholders replaces every placeholder of cmdStr by its value, quoted for the quotes around the
+// placeholder so that the shell always reads the value as literal text.
+func resolvePlaceholders(cmdStr string, values map[string]string) string {
+	var resolved strings.Builder
+	quote, escaped, last := rune(0), false, 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(cmdStr, -1) {
+		quote, escaped = shellQuoteAfter(cmdStr[last:loc[0]], quote)
+		value, ok := values[cmdStr[loc[2]:loc[3]]]
+		if !ok && loc[4] >= 0 {
+			value = strings.TrimSpace(cmdStr[loc[4]:loc[5]])
+		}
+		resolved.WriteString(cmdStr[last:loc[0]])
+		resolved.WriteString(shellQuoteIn(value, quote, escaped))
+		last = loc[1]
+	}
+	resolved.WriteString(cmdStr[last:])
+	return resolved.String()
+}
+
+// resolveCommand returns a copy of cmd with the placeholders of cmd, of its steps and of its batch resolved.
+func resolveCommand(cmd Cmd, values map[string]string) Cmd {
+	cmd.Cmd = resolvePlaceholders(cmd.Cmd, values)
+	steps := make([]Step, len(cmd.Steps))
+	for i, step := range cmd.Steps {
+		step.Cmd = resolvePlaceholders(step.Cmd, values)
+		steps[i] = step
+	}
+	if len(steps) > 0 {
+		cmd.Steps = steps
+	}
+	batch := make([]Cmd, len(cmd.Batch))
+	for i, member := range cmd.Batch {
+		batch[i] = resolveCommand(member, values)
+	}
+	if len(batch) > 0 {
+		cmd.Batch = batch
+	}
+	return cmd
+}
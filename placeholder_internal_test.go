@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestParsePlaceholders(t *testing.T) {
+	var tests = []struct {
+		cmdStr string
+		wat    []Placeholder
+	}{
+		{"date", nil},
+		{"ssh -i key user@{{host}}", []Placeholder{{Name: "host"}}},
+		{"git checkout {{ branch:main }}", []Placeholder{{Name: "branch", Default: "main"}}},
+		{"scp {{file}} {{host:10.0.0.1}}:{{file:x}}", []Placeholder{{Name: "file"}, {Name: "host", Default: "10.0.0.1"}}},
+		{"echo {{}} {{1abc}}", nil},
+	}
+	for _, tt := range tests {
+		got := parsePlaceholders(tt.cmdStr)
+		msg := fmt.Sprintf("cmdStr: %s", tt.cmdStr)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestResolvePlaceholders(t *testing.T) {
+	var tests = []struct {
+		cmdStr string
+		values map[string]string
+		wat    string
+	}{
+		{"ssh user@{{host}}", map[string]string{"host": "10.0.0.2"}, "ssh user@10.0.0.2"},
+		{"git checkout {{branch:main}}", map[string]string{}, "git checkout main"},
+		{"echo {{msg}}", map[string]string{"msg": "hello world"}, "echo 'hello world'"},
+		{"echo {{msg}}", map[string]string{"msg": "it's $HOME; rm -rf /"}, `echo 'it'"'"'s $HOME; rm -rf /'`},
+		{"echo {{msg}}", map[string]string{"msg": ""}, "echo ''"},
+		{"echo 'file: {{f}}'", map[string]string{"f": "$(echo INJECTED)"}, `echo 'file: ''$(echo INJECTED)'''`},
+		{"echo 'file: {{f}}'", map[string]string{"f": "report.txt"}, `echo 'file: 'report.txt''`},
+		{`echo "{{msg}}"`, map[string]string{"msg": "hello world"}, `echo "hello world"`},
+		{`echo "file: {{f}}"`, map[string]string{"f": "$(echo \"x\") `id` \\"}, `echo "file: \$(echo \"x\") ` + "\\`id\\`" + ` \\"`},
+		{`echo "it's" {{msg}} 'a "b"' {{msg}}`, map[string]string{"msg": "x y"}, `echo "it's" 'x y' 'a "b"' 'x y'`},
+		{`echo \' {{msg}}`, map[string]string{"msg": "x y"}, `echo \' 'x y'`},
+		{`echo \{{x}}`, map[string]string{"x": "a b"}, "echo \\\n'a b'"},
+		{`echo "\{{x}}"`, map[string]string{"x": "a b"}, `echo "\\a b"`},
+	}
+	for _, tt := range tests {
+		got := resolvePlaceholders(tt.cmdStr, tt.values)
+		msg := fmt.Sprintf("cmdStr: %s, values: %v", tt.cmdStr, tt.values)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestResolvePlaceholdersQuoting(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+	templates := []struct {
+		cmdStr string
+		wat    func(value string) string
+	}{
+		{"printf '%s' {{v}}", func(value string) string { return value }},
+		{"printf '%s' 'file: {{v}}!'", func(value string) string { return "file: " + value + "!" }},
+		{`printf '%s' "file: {{v}}!"`, func(value string) string { return "file: " + value + "!" }},
+		{`printf '%s' "it's" '{{v}}' "{{v}}"`, func(value string) string { return "it's" + value + value }},
+		{`printf '%s' \{{v}}`, func(value string) string { return value }},
+		{`printf '%s' "\{{v}}"`, func(value string) string { return `\` + value }},
+	}
+	roundTrip := func(value string) bool {
+		// bash arguments cannot contain NUL
+		value = strings.Replace(value, "\x00", "", -1)
+		for _, template := range templates {
+			cmdStr := resolvePlaceholders(template.cmdStr, map[string]string{"v": value})
+			out, err := exec.Command(bash, "-c", cmdStr).Output()
+			if got := string(out); err != nil || got != template.wat(value) {
+				t.Logf("%q\n\twat: %q\n\tgot: %q, %v", cmdStr, template.wat(value), got, err)
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, value := range []string{"hello world", "it's", `"quoted"`, "$(echo INJECTED)", "`id`", "a;b|c&d", "*", "~", "$HOME", "\\", "new\nline", ""} {
+		Equals(t, "value: "+value, true, roundTrip(value))
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
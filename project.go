@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const projectConfigName = ".c.yaml"
+
+// projectFiles returns every .c.yaml from dir up to the root, the nearest first, except the global config.
+func projectFiles(dir string) []string {
+	var files []string
+	globalFile, _ := filepath.Abs(configFile)
+	for {
+		projectFile := filepath.Join(dir, projectConfigName)
+		if _, err := os.Stat(projectFile); err == nil && projectFile != globalFile {
+			files = append(files, projectFile)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return files
+		}
+		dir = parent
+	}
+}
+
+// loadProjectCommands collects the commands of the project files of dir, see projectFiles.
+// They run in the directory of their file, a file that fails to parse is skipped with a warning.
+func loadProjectCommands(dir string) []Cmd {
+	var commands []Cmd
+	for _, projectFile := range projectFiles(dir) {
+		if data, err := ioutil.ReadFile(filepath.Clean(projectFile)); err == nil {
+			if diagnostics := validateConfig(projectFile, data); hasErrors(diagnostics) {
+				printDiagnostics(diagnostics)
+				color.Yellow("Skip %s, check it with: c validate", projectFile)
+			} else if projectConfig, err := parseConfig(data); err != nil {
+				color.Yellow("Skip %s, failed to parse: %v", projectFile, err)
+			} else {
+				commands = append(commands, withSource(projectConfig.Commands, projectFile, filepath.Dir(projectFile))...)
+			}
+		}
+	}
+	return commands
+}
+
+// withSource marks the tree as coming from sourceFile, running in dir unless a command sets its own.
+func withSource(commands []Cmd, sourceFile string, dir string) []Cmd {
+	marked := make([]Cmd, len(commands))
+	for i, command := range commands {
+		command.Source = sourceFile
+		if command.Dir == "" {
+			command.Dir = dir
+		}
+		command.Children = withSource(command.Children, sourceFile, dir)
+		marked[i] = command
+	}
+	return marked
+}
+
+// sourceLabel is the directory of a project command with the home directory shortened to ~.
+func (c Cmd) sourceLabel() string {
+	if c.Source == "" {
+		return ""
+	}
+	label := filepath.Dir(c.Source)
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(label, home) {
+		label = "~" + strings.TrimPrefix(label, home)
+	}
+	return label
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProjectCommands(t *testing.T) {
+	root, err := ioutil.TempDir("", "c-project")
+	Equals(t, "create temp dir", nil, err)
+	defer os.RemoveAll(root)
+
+	repoDir := filepath.Join(root, "repo")
+	subDir := filepath.Join(repoDir, "service")
+	workDir := filepath.Join(subDir, "cmd")
+	Equals(t, "create dirs", nil, os.MkdirAll(workDir, 0700))
+	Equals(t, "write repo file", nil, ioutil.WriteFile(filepath.Join(repoDir, projectConfigName), []byte("- name: test\n  cmd: make test"), 0600))
+	Equals(t, "write sub file", nil, ioutil.WriteFile(filepath.Join(subDir, projectConfigName), []byte("commands:\n- name: run\n  cmd: go run ."), 0600))
+
+	var got []Cmd
+	for _, cmd := range loadProjectCommands(workDir) {
+		got = append(got, Cmd{Name: cmd.Name, Dir: cmd.Dir, Source: cmd.Source})
+	}
+	wat := []Cmd{
+		{Name: "run", Dir: subDir, Source: filepath.Join(subDir, projectConfigName)},
+		{Name: "test", Dir: repoDir, Source: filepath.Join(repoDir, projectConfigName)},
+	}
+	Equals(t, "project commands", wat, got)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// queryFields are the fields a search term can be narrowed to with a field: prefix.
+var queryFields = []string{"name", "cmd", "tag", "alias"}
+
+// queryTerm is one term of a search query. An empty field matches the name or the cmd,
+// negate keeps only the commands the term does not match.
+type queryTerm struct {
+	field  string
+	value  string
+	negate bool
+}
+
+// parseQuery splits a search query into terms, all of them have to hold.
+// Terms are separated by spaces, double quotes keep spaces in a term: name:"deploy web".
+// A leading ! negates a term and a known field: prefix narrows it, like !tag:staging.
+func parseQuery(query string) []queryTerm {
+	var terms []queryTerm
+	for _, token := range splitQuery(query) {
+		var term queryTerm
+		if strings.HasPrefix(token, "!") {
+			term.negate = true
+			token = token[1:]
+		}
+		term.value = token
+		for _, field := range queryFields {
+			if strings.HasPrefix(token, field+":") {
+				term.field = field
+				term.value = token[len(field)+1:]
+				break
+			}
+		}
+		if term.value == "" {
+			// a term being typed, like "tag:" or "!", does not filter anything yet
+			continue
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// splitQuery splits query on spaces outside of double quotes and drops the quotes.
+func splitQuery(query string) []string {
+	var tokens []string
+	var token strings.Builder
+	quoted, inToken := false, false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inToken = true
+		case unicode.IsSpace(r) && !quoted:
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
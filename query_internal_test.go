@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	var tests = []struct {
+		query string
+		wat   []queryTerm
+	}{
+		{"", nil},
+		{"  ", nil},
+		{"deploy", []queryTerm{{value: "deploy"}}},
+		{"tag:prod name:deploy", []queryTerm{{field: "tag", value: "prod"}, {field: "name", value: "deploy"}}},
+		{"deploy !staging", []queryTerm{{value: "deploy"}, {value: "staging", negate: true}}},
+		{"!tag:staging alias:dp", []queryTerm{{field: "tag", value: "staging", negate: true}, {field: "alias", value: "dp"}}},
+		{`name:"deploy web" cmd:'x`, []queryTerm{{field: "name", value: "deploy web"}, {field: "cmd", value: "'x"}}},
+		{"http://host", []queryTerm{{value: "http://host"}}},
+		{"tag: ! deploy", []queryTerm{{value: "deploy"}}},
+		{`"tag:prod"`, []queryTerm{{field: "tag", value: "prod"}}},
+		{`"!a b"`, []queryTerm{{value: "a b", negate: true}}},
+	}
+	for _, tt := range tests {
+		msg := fmt.Sprintf("query: %s", tt.query)
+		Equals(t, msg, tt.wat, parseQuery(tt.query))
+	}
+}
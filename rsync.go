@@ -5,17 +5,52 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
-	"unicode"
 )
 
+// RsyncUploader builds the rsync commands copying files to and from the host of an ssh command.
+// Upload asks for the file itself and returns ErrRsNoFileChooser when it cannot,
+// the list then asks with its file picker and calls UploadFile.
+// Download pulls remotePath into localPath, both are asked by the list.
 type RsyncUploader interface {
 	Upload(cmd Cmd) (string, error)
+	UploadFile(cmd Cmd, path string) (string, error)
+	Download(cmd Cmd, remotePath string, localPath string) (string, error)
 }
 
 type RsyncPlugin struct{}
 
+// RsyncOptions is the rsync block of a command. Dest is the upload directory, the remote home when empty,
+// Flags replace -azP, Delete only applies to uploads.
+type RsyncOptions struct {
+	Dest    string   `yaml:"dest"`
+	Flags   string   `yaml:"flags"`
+	Exclude []string `yaml:"exclude"`
+	BwLimit string   `yaml:"bwlimit"`
+	Delete  bool     `yaml:"delete"`
+}
+
+const defaultRsyncFlags = "-azP"
+
+// args returns the rsync options before -e, with --delete when upload asks for it. They are quoted by shellCommand.
+func (o RsyncOptions) args(upload bool) []string {
+	flags := o.Flags
+	if strings.TrimSpace(flags) == "" {
+		flags = defaultRsyncFlags
+	}
+	args := strings.Fields(flags)
+	if o.Delete && upload {
+		args = append(args, "--delete")
+	}
+	if o.BwLimit != "" {
+		args = append(args, "--bwlimit="+o.BwLimit)
+	}
+	for _, pattern := range o.Exclude {
+		args = append(args, "--exclude="+pattern)
+	}
+	return args
+}
+
 const osaScript = "osascript"
-const darwin = "darwin"
 
 var chooseFileArgs = []string{
 	`-e`, `tell application "iTerm2" to activate`,
@@ -24,29 +59,27 @@ var chooseFileArgs = []string{
 }
 
 var (
-	ErrRsOs         = fmt.Errorf("rsync only supported on darwin")
-	ErrRsIterm2     = fmt.Errorf("rsync only supported on iTerm2")
-	ErrRsNotSSHCmd  = fmt.Errorf("rsync only supported cmd pattern: ssh -i key user@ip")
-	ErrRsUserCancel = fmt.Errorf("ignore: user canceled choose file")
+	ErrRsIterm2        = fmt.Errorf("rsync only supported on iTerm2")
+	ErrRsNotSSHCmd     = fmt.Errorf("rsync only supported cmd pattern: ssh [options] [user@]host")
+	ErrRsUserCancel    = fmt.Errorf("ignore: user canceled choose file")
+	ErrRsNoFileChooser = fmt.Errorf("ignore: %s is not available, choose the file in the list", osaScript)
+	ErrRsNoRemotePath  = fmt.Errorf("ignore: no remote path to download")
 )
 
-func (r RsyncPlugin) resolveSSHCmd(cmdStr string) ([]string, error) {
-	// pattern: ssh -i key user@ip
-	cmdFields := strings.FieldsFunc(cmdStr, func(r rune) bool {
-		return unicode.IsSpace(r)
-	})
-
-	if len(cmdFields) != 4 || strings.Join(cmdFields[:2], " ") != "ssh -i" || len(strings.Split(cmdFields[3], "@")) != 2 {
-		return []string{}, ErrRsNotSSHCmd
+// resolveSSHCmd parses the ssh command of cmdStr, see parseSSHCmd.
+func (r RsyncPlugin) resolveSSHCmd(cmdStr string) (sshTarget, error) {
+	target, err := parseSSHCmd(cmdStr)
+	if err != nil {
+		return target, err
 	}
-
-	return cmdFields, nil
+	debug("Rsync ssh target: %+v", target)
+	return target, nil
 }
 
 func (r RsyncPlugin) interactFile() (string, error) {
 	debug("Rsync platform: %+v", runtime.GOOS)
-	if runtime.GOOS != darwin {
-		return "", ErrRsOs
+	if _, err := exec.LookPath(osaScript); err != nil {
+		return "", ErrRsNoFileChooser
 	}
 
 	chooseFileOutputs, err := exec.Command(osaScript, chooseFileArgs...).CombinedOutput()
@@ -58,33 +91,43 @@ func (r RsyncPlugin) interactFile() (string, error) {
 		}
 	}
 
-	chooseFilePath := strings.TrimSpace(string(chooseFileOutputs))
+	// only the newline of echo is dropped, a file name can end with spaces
+	chooseFilePath := strings.TrimSuffix(string(chooseFileOutputs), "\n")
 	debug("Rsync chooseFilePath: %s", chooseFilePath)
 	return chooseFilePath, nil
 }
 
-func (r RsyncPlugin) buildRsyncCmd(cmdFields []string, chooseFilePath string) (string, error) {
-	// ssh -i /key
-	sshCmdStr := strings.Join(cmdFields[:3], " ")
-
-	// user@ip
-	destHost := cmdFields[3]
-	destUser := strings.Split(destHost, "@")[0]
-	destDir := fmt.Sprintf("/home/%s", destUser)
+// rsyncShell is the remote shell given to rsync -e. rsync splits it into words like a shell does,
+// so the options are quoted once for rsync here and the whole string once more for bash by shellCommand.
+func (t sshTarget) rsyncShell() string {
+	words := []string{"ssh"}
+	for _, option := range t.options {
+		words = append(words, shellQuote(option))
+	}
+	return strings.Join(words, " ")
+}
 
-	// user@ip:/home/user
-	destStr := fmt.Sprintf("%s:%s", destHost, destDir)
+// remotePath is path on the host of target, host is kept as written for the ssh config to apply.
+// Without a user, ssh picks it as for an interactive login.
+func (t sshTarget) remotePath(path string) string {
+	if t.user == "" {
+		return fmt.Sprintf("%s:%s", t.host, path)
+	}
+	return fmt.Sprintf("%s@%s:%s", t.user, t.host, path)
+}
 
-	// rsync -azP -e "ssh -i key" local_file  user@ip:/home/user
-	rsyncCmdStr := fmt.Sprintf(`rsync -azP -e "%s" %s %s`, sshCmdStr, chooseFilePath, destStr)
+func (r RsyncPlugin) buildRsyncCmd(target sshTarget, options RsyncOptions, chooseFilePath string) (string, error) {
+	// rsync -azP -e 'ssh -i key' -- local_file user@host:dest, no dest is the remote home.
+	// -- ends the options, so that a path like -rf is not read as one
+	words := append(append([]string{"rsync"}, options.args(true)...), "-e", target.rsyncShell(), "--", chooseFilePath, target.remotePath(options.Dest))
+	rsyncCmdStr := shellCommand(words)
 	debug("Rsync Cmd: %s", rsyncCmdStr)
 
 	return rsyncCmdStr, nil
 }
 
 func (r RsyncPlugin) Upload(cmd Cmd) (string, error) {
-	cmdFields, err := r.resolveSSHCmd(cmd.Cmd)
-	if err != nil {
+	if _, err := r.resolveSSHCmd(cmd.Cmd); err != nil {
 		return "", err
 	}
 
@@ -93,10 +136,44 @@ func (r RsyncPlugin) Upload(cmd Cmd) (string, error) {
 		return "", err
 	}
 
-	rsyncCmd, err := r.buildRsyncCmd(cmdFields, chooseFilePath)
+	return r.UploadFile(cmd, chooseFilePath)
+}
+
+func (r RsyncPlugin) UploadFile(cmd Cmd, path string) (string, error) {
+	target, err := r.resolveSSHCmd(cmd.Cmd)
+	if err != nil {
+		return "", err
+	}
+
+	rsyncCmd, err := r.buildRsyncCmd(target, cmd.Rsync, path)
 	if err != nil {
 		return "", err
 	}
 
 	return rsyncCmd, nil
 }
+
+func (r RsyncPlugin) buildDownloadCmd(target sshTarget, options RsyncOptions, remotePath string, localPath string) (string, error) {
+	if remotePath == "" {
+		return "", ErrRsNoRemotePath
+	}
+	if localPath == "" {
+		localPath = "."
+	}
+
+	// rsync -azP -e 'ssh -i key' -- user@host:remote_path local_path
+	words := append(append([]string{"rsync"}, options.args(false)...), "-e", target.rsyncShell(), "--", target.remotePath(remotePath), localPath)
+	rsyncCmdStr := shellCommand(words)
+	debug("Rsync Cmd: %s", rsyncCmdStr)
+
+	return rsyncCmdStr, nil
+}
+
+func (r RsyncPlugin) Download(cmd Cmd, remotePath string, localPath string) (string, error) {
+	target, err := r.resolveSSHCmd(cmd.Cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return r.buildDownloadCmd(target, cmd.Rsync, remotePath, localPath)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"os/exec"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/quick"
 )
 
 const chooseFilePath = "/fake/path"
@@ -17,27 +22,130 @@ func TestDetectInvalidCmd(t *testing.T) {
 	}{
 		{"", ErrRsNotSSHCmd},
 		{"xxx", ErrRsNotSSHCmd},
-		{"ssh user@ip", ErrRsNotSSHCmd},
-		{"ssh  -i key  user-ip", ErrRsNotSSHCmd},
+		{"ssh", ErrRsNotSSHCmd},
+		{"ssh -i", ErrRsNotSSHCmd},
+		{"ssh -Z user@ip", ErrRsNotSSHCmd},
+		{"ssh user@ip", nil},
+		{"ssh  -i key  user-ip", nil},
 		{"ssh  -i key  user@ip", nil},
 	}
 	for _, tt := range tests {
-		_, got := rs.resolveSSHCmd(tt.cmdStr)
+		_, err := rs.resolveSSHCmd(tt.cmdStr)
 		msg := fmt.Sprintf("cmdStr: %s", tt.cmdStr)
-		Equals(t, msg, tt.wat, got)
+		Equals(t, msg, tt.wat == nil, err == nil)
+		Equals(t, msg, tt.wat != nil, errors.Is(err, ErrRsNotSSHCmd))
 	}
 }
 
 func TestBuildRsyncCmd(t *testing.T) {
+	sshConfigFile = "/nonexistent/ssh_config"
+	defer func() { sshConfigFile = "~/.ssh/config" }()
+
 	var tests = []struct {
-		cmdStr []string
-		wat    string
+		cmdStr  string
+		options RsyncOptions
+		wat     string
 	}{
-		{strings.Split("ssh -i key user@ip", " "), `rsync -azP -e "ssh -i key" /fake/path user@ip:/home/user`},
+		{"ssh -i key user@ip", RsyncOptions{}, `rsync -azP -e 'ssh -i key' -- /fake/path user@ip:`},
+		{"ssh -p 2222 -o StrictHostKeyChecking=no -J jump user@ip", RsyncOptions{}, `rsync -azP -e 'ssh -p 2222 -o StrictHostKeyChecking=no -J jump' -- /fake/path user@ip:`},
+		{"ssh -t -L 8080:localhost:80 -l deploy ip tmux attach", RsyncOptions{}, `rsync -azP -e ssh -- /fake/path deploy@ip:`},
+		{"ssh ssh://user@ip:2222", RsyncOptions{}, `rsync -azP -e 'ssh -p 2222' -- /fake/path user@ip:`},
+		{"ssh user@ip -p 2222", RsyncOptions{}, `rsync -azP -e 'ssh -p 2222' -- /fake/path user@ip:`},
+		{"ssh ip -o Port=2200 -i key", RsyncOptions{}, `rsync -azP -e 'ssh -o Port=2200 -i key' -- /fake/path ip:`},
+		{"ssh -o RemoteCommand='tmux attach' -o RequestTTY=yes -o SessionType=default ip", RsyncOptions{}, `rsync -azP -e ssh -- /fake/path ip:`},
+		{
+			`ssh -o "ProxyCommand ssh -W %h:%p jump" user@ip`,
+			RsyncOptions{Dest: "/srv/my app"},
+			`rsync -azP -e 'ssh -o '"'"'ProxyCommand ssh -W %h:%p jump'"'"'' -- /fake/path 'user@ip:/srv/my app'`,
+		},
+		{
+			"ssh root@ip",
+			RsyncOptions{Dest: "/srv/app", Flags: "-rlt --partial", Exclude: []string{"*.log", ".git"}, BwLimit: "1m", Delete: true},
+			`rsync -rlt --partial --delete --bwlimit=1m '--exclude=*.log' --exclude=.git -e ssh -- /fake/path root@ip:/srv/app`,
+		},
 	}
 	for _, tt := range tests {
-		got, _ := rs.buildRsyncCmd(tt.cmdStr, chooseFilePath)
-		msg := fmt.Sprintf("cmdStr: %s", tt.cmdStr)
+		target, err := rs.resolveSSHCmd(tt.cmdStr)
+		msg := fmt.Sprintf("cmdStr: %s, options: %+v", tt.cmdStr, tt.options)
+		Equals(t, msg, nil, err)
+		got, _ := rs.buildRsyncCmd(target, tt.options, chooseFilePath)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestBuildDownloadCmd(t *testing.T) {
+	target, err := rs.resolveSSHCmd("ssh -p 2222 -i key user@ip")
+	Equals(t, "resolveSSHCmd", nil, err)
+
+	var tests = []struct {
+		options    RsyncOptions
+		remotePath string
+		localPath  string
+		wat        string
+		err        error
+	}{
+		{RsyncOptions{}, "/var/log/app.log", "/tmp", `rsync -azP -e 'ssh -p 2222 -i key' -- user@ip:/var/log/app.log /tmp`, nil},
+		{RsyncOptions{}, "dump.sql", "", `rsync -azP -e 'ssh -p 2222 -i key' -- user@ip:dump.sql .`, nil},
+		{RsyncOptions{BwLimit: "500", Delete: true}, "logs/", "/tmp/logs", `rsync -azP --bwlimit=500 -e 'ssh -p 2222 -i key' -- user@ip:logs/ /tmp/logs`, nil},
+		{RsyncOptions{}, "", ".", "", ErrRsNoRemotePath},
+	}
+	for _, tt := range tests {
+		got, err := rs.buildDownloadCmd(target, tt.options, tt.remotePath, tt.localPath)
+		msg := fmt.Sprintf("remotePath: %s, localPath: %s", tt.remotePath, tt.localPath)
+		Equals(t, msg, tt.err, err)
 		Equals(t, msg, tt.wat, got)
 	}
 }
+
+// TestRsyncCmdQuoting runs the generated commands through bash with rsync replaced by a function
+// printing its arguments, every path has to come out as it went in.
+func TestRsyncCmdQuoting(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+	target, err := rs.resolveSSHCmd(`ssh -o "ProxyCommand ssh -W %h:%p jump" -i '/keys/my key' user@ip`)
+	Equals(t, "resolveSSHCmd", nil, err)
+	rsyncArgs := func(rsyncCmd string) []string {
+		out, err := exec.Command(bash, "-c", `rsync() { printf '%s\0' "$@"; }; `+rsyncCmd).Output()
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return strings.Split(string(bytes.TrimSuffix(out, []byte{0})), "\x00")
+	}
+	shell := `ssh -o 'ProxyCommand ssh -W %h:%p jump' -i '/keys/my key'`
+
+	roundTrip := func(path string, dest string, exclude string) bool {
+		// paths cannot contain NUL, and bash arguments neither
+		path, dest, exclude = strings.Replace(path, "\x00", "", -1), strings.Replace(dest, "\x00", "", -1), strings.Replace(exclude, "\x00", "", -1)
+		options := RsyncOptions{Dest: dest, Exclude: []string{exclude}}
+
+		upload, _ := rs.buildRsyncCmd(target, options, path)
+		wat := []string{"-azP", "--exclude=" + exclude, "-e", shell, "--", path, "user@ip:" + dest}
+		if got := rsyncArgs(upload); !reflect.DeepEqual(wat, got) {
+			t.Logf("upload %q\n\twat: %q\n\tgot: %q", upload, wat, got)
+			return false
+		}
+
+		if path == "" {
+			return true
+		}
+		download, _ := rs.buildDownloadCmd(target, options, path, dest)
+		if dest == "" {
+			dest = "."
+		}
+		wat = []string{"-azP", "--exclude=" + exclude, "-e", shell, "--", "user@ip:" + path, dest}
+		if got := rsyncArgs(download); !reflect.DeepEqual(wat, got) {
+			t.Logf("download %q\n\twat: %q\n\tgot: %q", download, wat, got)
+			return false
+		}
+		return true
+	}
+
+	for _, path := range []string{"my file.txt", "it's", `"quoted"`, "$(touch /tmp/pwned)", "`id`", "a;b|c&d", "*", "~user", "-rf", "new\nline", "\\"} {
+		Equals(t, "path: "+path, true, roundTrip(path, path, path))
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// searchField tells which field of a command a term matched, name hits rank before cmd hits.
+type searchField int
+
+const (
+	nameField searchField = iota
+	tagField
+	cmdField
+)
+
+// searchHit is how a query matched a command, positions are the matched runes of the name and of the cmd.
+type searchHit struct {
+	cmd           Cmd
+	field         searchField
+	rank          int
+	namePositions []int
+	cmdPositions  []int
+}
+
+// matchTerm matches the value of term against the field it is narrowed to, by default the name then the cmd.
+// The negation of term is left to the caller.
+func matchTerm(m matcher, term queryTerm, cmd Cmd) (searchField, int, []int, bool) {
+	switch term.field {
+	case "name":
+		rank, positions, ok := m.Match(term.value, cmd.Name)
+		return nameField, rank, positions, ok
+	case "cmd":
+		rank, positions, ok := m.Match(term.value, cmd.Script())
+		return cmdField, rank, positions, ok
+	case "alias":
+		rank, _, ok := m.Match(term.value, cmd.Alias)
+		return tagField, rank, nil, ok
+	case "tag":
+		for _, tag := range cmd.Tags {
+			if rank, _, ok := m.Match(term.value, tag); ok {
+				return tagField, rank, nil, true
+			}
+		}
+		return tagField, 0, nil, false
+	}
+	if rank, positions, ok := m.Match(term.value, cmd.Name); ok {
+		return nameField, rank, positions, true
+	}
+	rank, positions, ok := m.Match(term.value, cmd.Script())
+	return cmdField, rank, positions, ok
+}
+
+// matchCommand reports whether cmd holds every term. The hit ranks by the worst field matched
+// and the sum of the ranks of the terms.
+func matchCommand(m matcher, terms []queryTerm, cmd Cmd) (searchHit, bool) {
+	hit := searchHit{cmd: cmd}
+	for _, term := range terms {
+		field, rank, positions, ok := matchTerm(m, term, cmd)
+		if ok == term.negate {
+			return searchHit{}, false
+		}
+		if term.negate {
+			continue
+		}
+		if field > hit.field {
+			hit.field = field
+		}
+		hit.rank += rank
+		switch field {
+		case nameField:
+			hit.namePositions = mergePositions(hit.namePositions, positions)
+		case cmdField:
+			hit.cmdPositions = mergePositions(hit.cmdPositions, positions)
+		}
+	}
+	return hit, true
+}
+
+// searchCommands returns the commands matching every term of query with m, the best matches first.
+// Equal matches keep the order of commands, all of them are returned for an empty query.
+func searchCommands(commands []Cmd, query string, m matcher) []Cmd {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return commands
+	}
+	var hits []searchHit
+	for _, v := range commands {
+		if hit, ok := matchCommand(m, terms, v); ok {
+			hits = append(hits, hit)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].field != hits[j].field {
+			return hits[i].field < hits[j].field
+		}
+		return hits[i].rank < hits[j].rank
+	})
+
+	var searchResult []Cmd
+	for _, hit := range hits {
+		searchResult = append(searchResult, hit.cmd)
+	}
+	return searchResult
+}
+
+// mergePositions returns the sorted union of two sets of rune positions.
+func mergePositions(a []int, b []int) []int {
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[int]bool, len(a)+len(b))
+	var merged []int
+	for _, position := range append(append([]int{}, a...), b...) {
+		if !seen[position] {
+			seen[position] = true
+			merged = append(merged, position)
+		}
+	}
+	sort.Ints(merged)
+	return merged
+}
+
+// matchPositions returns the indexes of the runes of target matching the runes of query in order,
+// taking the first occurrence of each. It is nil when query does not match.
+func matchPositions(query []rune, target []rune) []int {
+	if len(query) == 0 {
+		return nil
+	}
+	var positions []int
+	for i, r := range target {
+		if r == query[len(positions)] {
+			positions = append(positions, i)
+			if len(positions) == len(query) {
+				return positions
+			}
+		}
+	}
+	return nil
+}
+
+// highlight wraps text in style and the runes at positions in matchStyle, using termui's style markup.
+// An empty style leaves the text around the matches unstyled.
+func highlight(text string, positions []int, style string, matchStyle string) string {
+	var sb strings.Builder
+	var run []rune
+	runMatched := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		switch {
+		case runMatched:
+			sb.WriteString("[" + string(run) + "](" + matchStyle + ")")
+		case style != "":
+			sb.WriteString("[" + string(run) + "](" + style + ")")
+		default:
+			sb.WriteString(string(run))
+		}
+		run = run[:0]
+	}
+
+	next := 0
+	for i, r := range []rune(text) {
+		matched := next < len(positions) && positions[next] == i
+		if matched {
+			next++
+		}
+		if matched != runMatched {
+			flush()
+			runMatched = matched
+		}
+		run = append(run, r)
+	}
+	flush()
+	return sb.String()
+}
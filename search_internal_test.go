@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSearchCommands(t *testing.T) {
+	commands := []Cmd{
+		{Name: "restart db", Cmd: "systemctl restart postgresql", Tags: []string{"prod"}},
+		{Name: "tail logs", Cmd: "ssh db-1 tail -f /var/log/syslog", Alias: "tl"},
+		{Name: "db", Cmd: "psql", Tags: []string{"staging"}},
+		{Name: "dump db", Cmd: "pg_dump", Tags: []string{"prod", "backup"}},
+	}
+	var tests = []struct {
+		query string
+		wat   []string
+	}{
+		{"", []string{"restart db", "tail logs", "db", "dump db"}},
+		{"db", []string{"db", "dump db", "restart db", "tail logs"}},
+		{"tail", []string{"tail logs"}},
+		{"postgres", []string{"restart db"}},
+		{"nothing", nil},
+		{"db !dump", []string{"db", "restart db", "tail logs"}},
+		{"tag:prod", []string{"restart db", "dump db"}},
+		{"tag:prod name:dump", []string{"dump db"}},
+		{"!tag:prod", []string{"tail logs", "db"}},
+		{"cmd:db", []string{"tail logs"}},
+		{"alias:tl", []string{"tail logs"}},
+		{"logs tail", []string{"tail logs"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, cmd := range searchCommands(commands, tt.query, fuzzyMatcher{}) {
+			got = append(got, cmd.Name)
+		}
+		msg := fmt.Sprintf("query: %s", tt.query)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	var tests = []struct {
+		query string
+		text  string
+		style string
+		wat   string
+	}{
+		{"dpl", "deploy", "", "[d](fg:red)e[pl](fg:red)oy"},
+		{"dpl", "deploy", "fg:green", "[d](fg:red)[e](fg:green)[pl](fg:red)[oy](fg:green)"},
+		{"", "deploy", "fg:green", "[deploy](fg:green)"},
+		{"xyz", "deploy", "", "deploy"},
+		{"日志", "查看日志", "", "查看[日志](fg:red)"},
+	}
+	for _, tt := range tests {
+		msg := fmt.Sprintf("query: %s, text: %s", tt.query, tt.text)
+		Equals(t, msg, tt.wat, highlight(tt.text, matchPositions([]rune(tt.query), []rune(tt.text)), tt.style, "fg:red"))
+	}
+}
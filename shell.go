@@ -0,0 +1,77 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s as a single word for POSIX shells, leaving it untouched when that is safe.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// shellCommand joins words into a command line for POSIX shells, every word quoted by shellQuote.
+func shellCommand(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = shellQuote(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+var doubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`, "`", "\\`", `"`, `\"`)
+
+// shellQuoteIn quotes s as literal text for a command line where quote is open: ', " or 0 for none.
+// escaped tells that the text before s ends with a backslash escaping the first character of s.
+func shellQuoteIn(s string, quote rune, escaped bool) string {
+	switch quote {
+	case '\'':
+		// close the single quotes around the quoted word and open them again
+		return "'" + shellQuote(s) + "'"
+	case '"':
+		if escaped {
+			// the backslash stays literal before a character that is not special in double quotes
+			return `\` + doubleQuoteEscaper.Replace(s)
+		}
+		return doubleQuoteEscaper.Replace(s)
+	default:
+		if escaped {
+			// the backslash and the newline are a line continuation, removed by the shell
+			return "\n" + shellQuote(s)
+		}
+		return shellQuote(s)
+	}
+}
+
+// shellQuoteAfter returns the quote open after s for POSIX shells when quote is open before it,
+// and whether s ends with a backslash escaping what follows it.
+func shellQuoteAfter(s string, quote rune) (rune, bool) {
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			}
+		case r == '\\':
+			escaped = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		}
+	}
+	return quote, escaped
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// sshConfigFile is the ssh client config read to resolve host aliases, -F overrides it.
+var sshConfigFile = "~/.ssh/config"
+
+const (
+	// sshFlags are the ssh options without argument, sshArgOptions the ones taking one.
+	sshFlags      = "46AaCfGgKkMNnqsTtVvXxYy"
+	sshArgOptions = "BbcDEeFIiJLlmOoPpQRSWw"
+	// sshSessionOptions only make sense for an interactive session, they are dropped from rsync -e.
+	sshSessionOptions = "fGMNnsTtVXYDLOQRWw"
+)
+
+// sshSessionKeywords are the -o keywords of sshSessionOptions, they are dropped from rsync -e as well.
+var sshSessionKeywords = map[string]bool{
+	"controlmaster": true, "dynamicforward": true, "forkafterauthentication": true, "forwardx11": true,
+	"forwardx11trusted": true, "localcommand": true, "localforward": true, "permitlocalcommand": true,
+	"remotecommand": true, "remoteforward": true, "requesttty": true, "sessiontype": true, "stdinnull": true,
+}
+
+// sshTarget is the destination of an ssh command with the options used to reach it.
+type sshTarget struct {
+	// options are the transport options of the command, in order, as given to rsync -e.
+	options []string
+	// user and host are the ones written in the command, user is filled from -l, -o User or the ssh config,
+	// it stays empty for ssh to pick it when none of them has one.
+	user string
+	host string
+	// hostname and port are resolved through the ssh config, hostname is host when no config applies.
+	hostname     string
+	port         string
+	identityFile string
+	configFile   string
+}
+
+// parseSSHCmd parses an ssh command like `ssh [options] [user@]host [command]`, or an ssh:// URL,
+// and resolves its host in the ssh config.
+func parseSSHCmd(cmdStr string) (sshTarget, error) {
+	fields, err := shellFields(cmdStr)
+	if err != nil || len(fields) == 0 || filepath.Base(fields[0]) != "ssh" {
+		return sshTarget{}, ErrRsNotSSHCmd
+	}
+	target, err := parseSSHArgs(fields[1:])
+	if err != nil {
+		return target, err
+	}
+	configFile := target.configFile
+	if configFile == "" {
+		configFile = sshConfigFile
+	}
+	blocks, err := loadSSHConfig(expandHome(configFile))
+	if err != nil && !os.IsNotExist(err) {
+		debug("Load ssh config get: %v", err)
+	}
+	target.resolve(blocks)
+	return target, nil
+}
+
+// parseSSHArgs splits the arguments of ssh into its options and the destination, the remote command is ignored.
+func parseSSHArgs(args []string) (sshTarget, error) {
+	var target sshTarget
+	destination := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if destination == "" && i+1 < len(args) {
+				destination = args[i+1]
+			}
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			// like ssh, options may follow the destination up to the first word of the remote command
+			if destination != "" {
+				break
+			}
+			destination = arg
+			continue
+		}
+		for j := 1; j < len(arg); j++ {
+			option := arg[j]
+			if strings.IndexByte(sshFlags, option) >= 0 {
+				if strings.IndexByte(sshSessionOptions, option) < 0 {
+					target.options = append(target.options, "-"+string(option))
+				}
+				continue
+			}
+			if strings.IndexByte(sshArgOptions, option) < 0 {
+				return target, fmt.Errorf("%w: unknown option -%c", ErrRsNotSSHCmd, option)
+			}
+			value := arg[j+1:]
+			if value == "" {
+				if i+1 >= len(args) {
+					return target, fmt.Errorf("%w: option -%c needs an argument", ErrRsNotSSHCmd, option)
+				}
+				i++
+				value = args[i]
+			}
+			target.setOption(option, value)
+			break
+		}
+	}
+	if destination == "" {
+		return target, fmt.Errorf("%w: no destination", ErrRsNotSSHCmd)
+	}
+	return target, target.setDestination(destination)
+}
+
+func (t *sshTarget) setOption(option byte, value string) {
+	switch option {
+	case 'l':
+		t.user = value
+		return
+	case 'p':
+		t.port = value
+	case 'i':
+		t.identityFile = expandHome(value)
+	case 'F':
+		t.configFile = value
+	case 'o':
+		key, optionValue := splitSSHOption(value)
+		switch key {
+		case "user":
+			t.user = optionValue
+			return
+		case "port":
+			t.port = optionValue
+		case "hostname":
+			t.hostname = optionValue
+		case "identityfile":
+			t.identityFile = expandHome(optionValue)
+		}
+		if sshSessionKeywords[key] {
+			return
+		}
+	}
+	if strings.IndexByte(sshSessionOptions, option) < 0 {
+		t.options = append(t.options, "-"+string(option), value)
+	}
+}
+
+// setDestination reads [user@]host or ssh://[user@]host[:port], the user and the port of the command line win.
+func (t *sshTarget) setDestination(destination string) error {
+	port := ""
+	if strings.HasPrefix(destination, "ssh://") {
+		destination = strings.TrimSuffix(strings.TrimPrefix(destination, "ssh://"), "/")
+		if i := strings.LastIndex(destination, ":"); i >= 0 && !strings.HasSuffix(destination, "]") {
+			destination, port = destination[:i], destination[i+1:]
+		}
+	}
+	if i := strings.LastIndex(destination, "@"); i >= 0 {
+		if t.user == "" {
+			t.user = destination[:i]
+		}
+		destination = destination[i+1:]
+	}
+	if destination == "" {
+		return fmt.Errorf("%w: invalid destination", ErrRsNotSSHCmd)
+	}
+	t.host = destination
+	if port != "" && t.port == "" {
+		t.port = port
+		t.options = append(t.options, "-p", port)
+	}
+	return nil
+}
+
+// resolve fills what the command does not say from the ssh config.
+func (t *sshTarget) resolve(blocks []sshHostBlock) {
+	config := lookupSSHConfig(blocks, t.host)
+	if t.user == "" {
+		t.user = config["user"]
+	}
+	if t.hostname == "" {
+		t.hostname = strings.Replace(config["hostname"], "%h", t.host, -1)
+	}
+	if t.hostname == "" {
+		t.hostname = t.host
+	}
+	if t.port == "" {
+		t.port = config["port"]
+	}
+	if t.identityFile == "" && config["identityfile"] != "" {
+		t.identityFile = expandHome(config["identityfile"])
+	}
+}
+
+func splitSSHOption(option string) (string, string) {
+	option = strings.TrimSpace(option)
+	i := strings.IndexAny(option, "= \t")
+	if i < 0 {
+		return strings.ToLower(option), ""
+	}
+	return strings.ToLower(option[:i]), strings.Trim(strings.TrimSpace(option[i+1:]), `"`)
+}
+
+// sshHostBlock is a Host section of the ssh config with its options by lower-cased keyword.
+type sshHostBlock struct {
+	patterns []string
+	options  map[string]string
+}
+
+// loadSSHConfig reads the Host sections of an ssh config. Match sections are skipped, Include is not followed.
+func loadSSHConfig(file string) ([]sshHostBlock, error) {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// options before the first Host apply to every host
+	blocks := []sshHostBlock{{patterns: []string{"*"}, options: map[string]string{}}}
+	current := &blocks[0]
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value := splitSSHOption(line)
+		switch key {
+		case "host":
+			blocks = append(blocks, sshHostBlock{patterns: strings.Fields(value), options: map[string]string{}})
+			current = &blocks[len(blocks)-1]
+		case "match":
+			current = nil
+		default:
+			// the first value of a keyword wins, like in ssh
+			if current == nil {
+				continue
+			}
+			if _, ok := current.options[key]; !ok {
+				current.options[key] = value
+			}
+		}
+	}
+	return blocks, scanner.Err()
+}
+
+// lookupSSHConfig merges the options of the blocks matching host, the first value of a keyword wins.
+func lookupSSHConfig(blocks []sshHostBlock, host string) map[string]string {
+	options := map[string]string{}
+	for _, block := range blocks {
+		if !block.matches(host) {
+			continue
+		}
+		for key, value := range block.options {
+			if _, ok := options[key]; !ok {
+				options[key] = value
+			}
+		}
+	}
+	return options
+}
+
+// matches tells whether host matches one of the patterns and none of the negated ones.
+func (b sshHostBlock) matches(host string) bool {
+	matched := false
+	for _, pattern := range b.patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), host)
+		if ok && negated {
+			return false
+		}
+		matched = matched || ok && !negated
+	}
+	return matched
+}
+
+// shellFields splits a command line into words like a POSIX shell, without expansions.
+func shellFields(s string) ([]string, error) {
+	var fields []string
+	var word strings.Builder
+	inWord, quote := false, rune(0)
+	escaped, escapedInQuotes := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"' && escapedInQuotes:
+			// in double quotes a backslash only escapes $, `, " and itself
+			if !strings.ContainsRune("$`\"\\", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escapedInQuotes = false
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escapedInQuotes = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				fields = append(fields, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped || escapedInQuotes {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		fields = append(fields, word.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSSHArgs(t *testing.T) {
+	var tests = []struct {
+		cmdStr string
+		wat    sshTarget
+	}{
+		{"ssh -i ~/key user@ip", sshTarget{options: []string{"-i", "~/key"}, user: "user", host: "ip", identityFile: expandHome("~/key")}},
+		{"ssh -4Cp2222 ip", sshTarget{options: []string{"-4", "-C", "-p", "2222"}, host: "ip", port: "2222"}},
+		{`ssh -o "ProxyCommand ssh -W %h:%p jump" -o User=deploy ip`, sshTarget{options: []string{"-o", "ProxyCommand ssh -W %h:%p jump"}, user: "deploy", host: "ip"}},
+		{"ssh -l root -F ./ssh_config admin@ip uptime", sshTarget{options: []string{"-F", "./ssh_config"}, user: "root", host: "ip", configFile: "./ssh_config"}},
+		{"ssh -N -D 1080 -- ip", sshTarget{host: "ip"}},
+		{"ssh user@host -p 2222", sshTarget{options: []string{"-p", "2222"}, user: "user", host: "host", port: "2222"}},
+		{"ssh host -o Port=2200 -i key", sshTarget{options: []string{"-o", "Port=2200", "-i", "key"}, host: "host", port: "2200", identityFile: "key"}},
+		{"ssh host -t tmux attach -d", sshTarget{host: "host"}},
+		{"ssh -p 22 host -- ls -l", sshTarget{options: []string{"-p", "22"}, host: "host", port: "22"}},
+		{"ssh -o RemoteCommand=uptime -o RequestTTY=force -o SessionType=none -o ForwardAgent=yes host",
+			sshTarget{options: []string{"-o", "ForwardAgent=yes"}, host: "host"}},
+	}
+	for _, tt := range tests {
+		fields, err := shellFields(tt.cmdStr)
+		Equals(t, "shellFields "+tt.cmdStr, nil, err)
+		got, err := parseSSHArgs(fields[1:])
+		msg := fmt.Sprintf("cmdStr: %s", tt.cmdStr)
+		Equals(t, msg, nil, err)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestParseSSHCmdWithConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-ssh")
+	Equals(t, "TempDir", nil, err)
+	defer os.RemoveAll(dir)
+	config := `# servers
+IdentityFile ~/.ssh/global
+
+Host web web-*
+    HostName %h.example.com
+    User deploy
+    Port 2222
+
+Host *.internal !db.internal
+    User ops
+
+Match user root
+    User nobody
+
+Host *
+    User fallback
+`
+	sshConfigFile = filepath.Join(dir, "config")
+	defer func() { sshConfigFile = "~/.ssh/config" }()
+	Equals(t, "WriteFile", nil, ioutil.WriteFile(sshConfigFile, []byte(config), 0600))
+
+	var tests = []struct {
+		cmdStr   string
+		user     string
+		hostname string
+		port     string
+	}{
+		{"ssh web", "deploy", "web.example.com", "2222"},
+		{"ssh -p 22 admin@web-1", "admin", "web-1.example.com", "22"},
+		{"ssh api.internal", "ops", "api.internal", ""},
+		{"ssh db.internal", "fallback", "db.internal", ""},
+	}
+	for _, tt := range tests {
+		target, err := parseSSHCmd(tt.cmdStr)
+		msg := fmt.Sprintf("cmdStr: %s", tt.cmdStr)
+		Equals(t, msg, nil, err)
+		Equals(t, msg, []string{tt.user, tt.hostname, tt.port, expandHome("~/.ssh/global")},
+			[]string{target.user, target.hostname, target.port, target.identityFile})
+	}
+
+	// without a user in the command or the config, ssh picks it
+	target, err := parseSSHCmd("ssh -F /dev/null db.internal")
+	Equals(t, "no user", nil, err)
+	Equals(t, "no user", "", target.user)
+	Equals(t, "no user remote path", "db.internal:/srv", target.remotePath("/srv"))
+}
+
+func TestShellFields(t *testing.T) {
+	var tests = []struct {
+		s   string
+		wat []string
+	}{
+		{"ssh  -i key\tuser@ip", []string{"ssh", "-i", "key", "user@ip"}},
+		{`ssh -o 'User x' "a b" a\ b`, []string{"ssh", "-o", "User x", "a b", "a b"}},
+		{`echo "\$HOME \d" ''`, []string{"echo", `$HOME \d`, ""}},
+	}
+	for _, tt := range tests {
+		got, err := shellFields(tt.s)
+		Equals(t, "s: "+tt.s, nil, err)
+		Equals(t, "s: "+tt.s, tt.wat, got)
+	}
+	_, err := shellFields(`ssh "ip`)
+	Equals(t, "unterminated quote", `unterminated quote or escape in "ssh \"ip"`, fmt.Sprint(err))
+}
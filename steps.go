@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Step is one command of a pipeline, see Cmd.Steps.
+type Step struct {
+	Name            string `yaml:"name"`
+	Cmd             string `yaml:"cmd"`
+	ContinueOnError bool   `yaml:"continue_on_error"`
+}
+
+func (s Step) displayName(index int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return fmt.Sprintf("step %d", index+1)
+}
+
+// IsPipeline reports whether the command runs a list of steps instead of cmd.
+func (c Cmd) IsPipeline() bool {
+	return len(c.Steps) > 0
+}
+
+// Runnable reports whether there is anything to run, the list sends an empty Cmd to exit.
+func (c Cmd) Runnable() bool {
+	return c.Cmd != "" || c.IsPipeline() || c.IsBatch()
+}
+
+// Script is the shell line of the command. Steps of a pipeline run in subshells chained by &&,
+// those continuing on error cannot break the chain.
+func (c Cmd) Script() string {
+	if c.IsBatch() {
+		return c.batchScript()
+	}
+	if !c.IsPipeline() {
+		return c.Cmd
+	}
+	parts := make([]string, len(c.Steps))
+	for i, step := range c.Steps {
+		if step.ContinueOnError {
+			parts[i] = fmt.Sprintf("{ (%s) || true; }", step.Cmd)
+		} else {
+			parts[i] = fmt.Sprintf("(%s)", step.Cmd)
+		}
+	}
+	return strings.Join(parts, " && ")
+}
+
+// summaryRow is one line of the table printed after a pipeline or a batch.
+type summaryRow struct {
+	name    string
+	result  RunResult
+	skipped bool
+}
+
+// runSteps runs the steps of cmd one after another in dir with env, stopping at the first failure
+// unless that step continues on error, then prints a summary of every step.
+// The exit code is the one of the step that stopped the pipeline, 0 when none did.
+func runSteps(cmd Cmd, dir string, env []string) RunResult {
+	var pipelineResult RunResult
+	results := make([]summaryRow, len(cmd.Steps))
+	stopped := false
+	for i, step := range cmd.Steps {
+		results[i].name = step.displayName(i)
+		if stopped {
+			results[i].skipped = true
+			continue
+		}
+
+		progress := fmt.Sprintf("[%d/%d] %s", i+1, len(cmd.Steps), results[i].name)
+		fmt.Println(color.CyanString("%s :", progress), color.GreenString(step.Cmd))
+		result := runChild(step.Cmd, dir, env)
+		results[i].result = result
+		pipelineResult.Duration += result.Duration
+
+		duration := result.Duration.Round(time.Millisecond)
+		switch {
+		case result.ExitCode == 0:
+			color.Green("%s ok, took %v", progress, duration)
+		case step.ContinueOnError:
+			color.Yellow("%s failed with exit status %d, took %v, continue on error", progress, result.ExitCode, duration)
+		default:
+			color.Red("%s failed with exit status %d, took %v, stop", progress, result.ExitCode, duration)
+			pipelineResult.ExitCode = result.ExitCode
+			stopped = true
+		}
+	}
+
+	printSummary("STEP", results)
+	printRunResult(pipelineResult)
+	return pipelineResult
+}
+
+func printSummary(column string, results []summaryRow) {
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tSTATUS\tDURATION\n", column)
+	for _, r := range results {
+		switch {
+		case r.skipped:
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", r.name, "skipped", "-")
+		case r.result.ExitCode == 0:
+			fmt.Fprintf(tw, "%s\t%s\t%v\n", r.name, "ok", r.result.Duration.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(tw, "%s\texit %d\t%v\n", r.name, r.result.ExitCode, r.result.Duration.Round(time.Millisecond))
+		}
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCmdScript(t *testing.T) {
+	var tests = []struct {
+		cmd Cmd
+		wat string
+	}{
+		{Cmd{Cmd: "date"}, "date"},
+		{Cmd{Steps: []Step{{Cmd: "make"}}}, "(make)"},
+		{Cmd{Steps: []Step{{Cmd: "make test"}, {Cmd: "lint; vet", ContinueOnError: true}, {Cmd: "make push"}}},
+			"(make test) && { (lint; vet) || true; } && (make push)"},
+	}
+	for _, tt := range tests {
+		msg := fmt.Sprintf("cmd: %+v", tt.cmd)
+		Equals(t, msg, tt.wat, tt.cmd.Script())
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	cmd := Cmd{Steps: []Step{{Name: "build", Cmd: "make {{target}}"}, {Cmd: "deploy {{target}} {{env:prod}}"}}}
+	got := resolveCommand(cmd, map[string]string{"target": "web app", "env": "prod"})
+	Equals(t, "steps", []Step{{Name: "build", Cmd: "make 'web app'"}, {Cmd: "deploy 'web app' prod"}}, got.Steps)
+	Equals(t, "original", "make {{target}}", cmd.Steps[0].Cmd)
+}
@@ -1,26 +1,52 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 )
 
+// RunResult describes how a command run by RunCommand ended.
+type RunResult struct {
+	ExitCode int
+	Duration time.Duration
+}
+
 func ExecCommand(cmd Cmd) {
-	if cmd.Cmd == "" {
+	if !cmd.Runnable() {
 		os.Exit(0)
 	}
+	if cmd.IsPipeline() {
+		// every step has to be waited for, so c cannot be replaced
+		os.Exit(RunCommand(cmd).ExitCode)
+	}
 	bash, err := exec.LookPath("bash")
 	if err != nil {
 		panic(err)
 	}
 	args := []string{"bash", "-c", cmd.Cmd}
-	env := os.Environ()
+	dir, env, err := commandContext(cmd)
+	if err != nil {
+		color.Red("Failed to prepare %s: %v", cmd.Name, err)
+		os.Exit(1)
+	}
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			color.Red("Failed to enter %s: %v", dir, err)
+			os.Exit(1)
+		}
+	}
 
-	printCmdInfo(cmd)
+	printCmdInfo(cmd, dir)
+	recordHistory(newHistoryEntry(cmd, dir))
 
 	execErr := syscall.Exec(bash, args, env)
 	if execErr != nil {
@@ -28,6 +54,151 @@ func ExecCommand(cmd Cmd) {
 	}
 }
 
-func printCmdInfo(cmd Cmd) {
-	fmt.Println(color.RedString("Execute %s :", cmd.Name), color.GreenString(cmd.Cmd))
+// PrintCommand writes the command to stdout instead of running it, so that a shell widget can edit it.
+// It exits with status 1 when nothing was selected.
+func PrintCommand(cmd Cmd) {
+	if !cmd.Runnable() {
+		os.Exit(1)
+	}
+	script, err := printedScript(cmd)
+	if err != nil {
+		color.Red("Failed to prepare %s: %v", cmd.Name, err)
+		os.Exit(1)
+	}
+	fmt.Println(script)
+	os.Exit(0)
+}
+
+// printedScript is the script of cmd with the variables of its env and env_file exported, run from its
+// directory when it has one. The subshell leaves the shell the line is run from alone, and nothing runs
+// when the cd fails.
+func printedScript(cmd Cmd) (string, error) {
+	dir, env, err := commandContext(cmd)
+	if err != nil {
+		return "", err
+	}
+	var prefix []string
+	if exports := envChanges(env); len(exports) > 0 {
+		prefix = append(prefix, "export "+shellCommand(exports))
+	}
+	if dir != "" {
+		prefix = append(prefix, fmt.Sprintf("cd %s || exit", shellQuote(dir)))
+	}
+	if len(prefix) == 0 {
+		return cmd.Script(), nil
+	}
+	return subshell(strings.Join(append(prefix, cmd.Script()), "; ")), nil
+}
+
+// envChanges returns the variables of env that the environment of c does not have with the same value.
+func envChanges(env []string) []string {
+	current := make(map[string]bool)
+	for _, v := range os.Environ() {
+		current[v] = true
+	}
+	var changes []string
+	for _, v := range env {
+		if !current[v] {
+			changes = append(changes, v)
+		}
+	}
+	return changes
+}
+
+func subshell(script string) string {
+	if strings.Contains(script, "#") {
+		// a comment at the end of script would hide the closing parenthesis
+		return "(" + script + "\n)"
+	}
+	return "(" + script + ")"
+}
+
+// RunCommand runs cmd as a child process attached to the terminal and waits for it,
+// unlike ExecCommand it returns so that the list can be shown again.
+func RunCommand(cmd Cmd) RunResult {
+	if !cmd.Runnable() {
+		os.Exit(0)
+	}
+	dir, env, err := commandContext(cmd)
+	if err != nil {
+		color.Red("Failed to prepare %s: %v", cmd.Name, err)
+		return RunResult{ExitCode: -1}
+	}
+
+	printCmdInfo(cmd, dir)
+	entry := newHistoryEntry(cmd, dir)
+
+	var result RunResult
+	if cmd.IsPipeline() {
+		result = runSteps(cmd, dir, env)
+	} else {
+		result = runChild(cmd.Cmd, dir, env)
+		printRunResult(result)
+	}
+	recordHistory(entry.withResult(result))
+	return result
+}
+
+// runChild runs script with bash in dir with env, attached to the terminal, and waits for it.
+func runChild(script string, dir string, env []string) RunResult {
+	child := bashCommand(script, dir, env)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	defer ignoreInterrupts()()
+	return waitChild(child)
+}
+
+func bashCommand(script string, dir string, env []string) *exec.Cmd {
+	child := exec.Command("bash", "-c", script)
+	child.Dir = dir
+	child.Env = env
+	return child
+}
+
+// ignoreInterrupts keeps c alive on Ctrl-C until the returned function is called.
+// Children are in the foreground process group, so they receive Ctrl-C from the terminal itself,
+// handled signals are reset in them.
+func ignoreInterrupts() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGQUIT)
+	return func() { signal.Stop(signals) }
+}
+
+func waitChild(child *exec.Cmd) RunResult {
+	start := time.Now()
+	err := child.Run()
+	result := RunResult{Duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		color.Red("Failed to run: %v", err)
+		result.ExitCode = -1
+	}
+	return result
+}
+
+// WaitForReturn blocks until the user presses Enter, so the output stays visible before the list is back.
+func WaitForReturn() {
+	fmt.Print(color.CyanString("Press Enter to return to the list..."))
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+}
+
+func printRunResult(result RunResult) {
+	duration := result.Duration.Round(time.Millisecond)
+	if result.ExitCode == 0 {
+		color.Green("Exit status %d, took %v", result.ExitCode, duration)
+	} else {
+		color.Red("Exit status %d, took %v", result.ExitCode, duration)
+	}
+}
+
+func printCmdInfo(cmd Cmd, dir string) {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	fmt.Println(color.RedString("Execute %s in %s :", cmd.Name, dir), color.GreenString(cmd.Script()))
 }
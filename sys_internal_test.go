@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrintedScript(t *testing.T) {
+	home, err := os.UserHomeDir()
+	Equals(t, "home dir", nil, err)
+	defaultConfigFile := configFile
+	configFile = "/etc/c/config.yaml"
+	defer func() { configFile = defaultConfigFile }()
+
+	var tests = []struct {
+		cmd Cmd
+		wat string
+	}{
+		{Cmd{Cmd: "make"}, "make"},
+		{Cmd{Cmd: "make", Dir: "/srv/web"}, "(cd /srv/web || exit; make)"},
+		{Cmd{Cmd: "make", Dir: "/srv/my web"}, "(cd '/srv/my web' || exit; make)"},
+		{Cmd{Cmd: "make", Dir: "~/src/web"}, "(cd " + shellQuote(filepath.Join(home, "src/web")) + " || exit; make)"},
+		{Cmd{Cmd: "make", Dir: "web"}, "(cd /etc/c/web || exit; make)"},
+		{Cmd{Cmd: "make", Dir: "web", Source: "/src/app/.c.yaml"}, "(cd /src/app/web || exit; make)"},
+		{Cmd{Steps: []Step{{Cmd: "make"}, {Cmd: "make test"}}, Dir: "/srv"}, "(cd /srv || exit; (make) && (make test))"},
+		{Cmd{Cmd: "make || echo failed; make test", Dir: "/srv"}, "(cd /srv || exit; make || echo failed; make test)"},
+		{Cmd{Cmd: "make & make test &", Dir: "/srv"}, "(cd /srv || exit; make & make test &)"},
+		{Cmd{Cmd: "make # build", Dir: "/srv"}, "(cd /srv || exit; make # build\n)"},
+	}
+	for _, tt := range tests {
+		got, err := printedScript(tt.cmd)
+		msg := fmt.Sprintf("cmd: %+v", tt.cmd)
+		Equals(t, msg, nil, err)
+		Equals(t, msg, tt.wat, got)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+	script, _ := printedScript(Cmd{Cmd: "echo ran || echo failed; echo ran", Dir: "/nonexistent"})
+	out, err := exec.Command(bash, "-c", script).Output()
+	Equals(t, "nothing runs when cd fails", "", string(out))
+	Equals(t, "cd failure is the exit status", true, err != nil)
+	script, _ = printedScript(Cmd{Cmd: "pwd # where", Dir: "/"})
+	out, err = exec.Command(bash, "-c", script+"; pwd").Output()
+	Equals(t, "run in dir", nil, err)
+	cwd, _ := os.Getwd()
+	Equals(t, "only the subshell changes dir", "/\n"+cwd+"\n", string(out))
+}
+
+func TestPrintedScriptEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "c-print")
+	Equals(t, "create temp dir", nil, err)
+	defer os.RemoveAll(dir)
+	Equals(t, "write env file", nil, ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("APP_ENV=prod\nGREETING='hello world'\n"), 0600))
+	Equals(t, "set C_PRINT_HOME", nil, os.Setenv("C_PRINT_HOME", "/home/c"))
+	defer os.Unsetenv("C_PRINT_HOME")
+	source := filepath.Join(dir, projectConfigName)
+
+	var tests = []struct {
+		cmd Cmd
+		wat string
+	}{
+		{Cmd{Cmd: "make", Env: map[string]string{"FOO": "1"}}, "(export FOO=1; make)"},
+		{Cmd{Cmd: "make", Env: map[string]string{"C_PRINT_HOME": "/home/c"}}, "make"},
+		{Cmd{Cmd: "make", Env: map[string]string{"B": "it's", "A": "$C_PRINT_HOME/x"}, Dir: "/srv"},
+			`(export A=/home/c/x 'B=it'"'"'s'; cd /srv || exit; make)`},
+		{Cmd{Cmd: "make", EnvFile: ".env", Env: map[string]string{"APP_ENV": "dev"}, Source: source},
+			"(export APP_ENV=dev 'GREETING=hello world'; make)"},
+	}
+	for _, tt := range tests {
+		got, err := printedScript(tt.cmd)
+		msg := fmt.Sprintf("cmd: %+v", tt.cmd)
+		Equals(t, msg, nil, err)
+		Equals(t, msg, tt.wat, got)
+	}
+
+	_, err = printedScript(Cmd{Cmd: "make", EnvFile: "missing.env", Source: source})
+	Equals(t, "missing env file", true, err != nil)
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+	script, _ := printedScript(Cmd{Cmd: `echo "$APP_ENV $GREETING" && sh -c 'echo $APP_ENV'`, EnvFile: ".env", Source: source})
+	out, err := exec.Command(bash, "-c", script+`; echo "[$APP_ENV]"`).Output()
+	Equals(t, "run with env", nil, err)
+	Equals(t, "env is exported to the command only", "prod hello world\nprod\n[]\n", string(out))
+}
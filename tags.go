@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// tagCount is a tag of the config with the number of commands carrying it.
+type tagCount struct {
+	tag   string
+	count int
+}
+
+// collectTags returns every tag of commands, sorted by name.
+func collectTags(commands []Cmd) []tagCount {
+	counts := make(map[string]int)
+	for _, cmd := range flattenCommands(commands) {
+		for _, tag := range cmd.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, tagCount{tag: tag, count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].tag < tags[j].tag })
+	return tags
+}
+
+// hasTags reports whether cmd carries every one of tags.
+func (c Cmd) hasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, own := range c.Tags {
+			if own == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterByTags keeps the commands carrying every one of tags, and the groups containing some of them.
+func filterByTags(commands []Cmd, tags []string) []Cmd {
+	if len(tags) == 0 {
+		return commands
+	}
+	var filtered []Cmd
+	for _, cmd := range commands {
+		if cmd.IsGroup() {
+			if children := filterByTags(cmd.Children, tags); len(children) > 0 {
+				cmd.Children = children
+				filtered = append(filtered, cmd)
+			}
+			continue
+		}
+		if cmd.hasTags(tags) {
+			filtered = append(filtered, cmd)
+		}
+	}
+	return filtered
+}
+
+// tagBadges renders tags as colored badges, a tag always gets the same color of the theme.
+func tagBadges(tags []string) string {
+	var badges []string
+	for _, tag := range tags {
+		if len(theme.Badges) == 0 {
+			badges = append(badges, styled(" "+tag+" ", "", "reverse"))
+			continue
+		}
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(tag))
+		background := theme.Badges[hash.Sum32()%uint32(len(theme.Badges))]
+		badges = append(badges, fmt.Sprintf("[ %s ](%s,bg:%s)", tag, style(theme.BadgeText), background))
+	}
+	return strings.Join(badges, " ")
+}
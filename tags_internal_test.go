@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterByTags(t *testing.T) {
+	commands := []Cmd{
+		{Name: "deploy", Tags: []string{"prod", "web"}},
+		{Name: "db", Children: []Cmd{
+			{Name: "dump", Tags: []string{"prod", "db"}},
+			{Name: "psql", Tags: []string{"staging", "db"}},
+		}},
+		{Name: "date"},
+	}
+	var tests = []struct {
+		tags []string
+		wat  []string
+	}{
+		{nil, []string{"deploy", "db/dump", "db/psql", "date"}},
+		{[]string{"prod"}, []string{"deploy", "db/dump"}},
+		{[]string{"prod", "db"}, []string{"db/dump"}},
+		{[]string{"staging", "web"}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, cmd := range flattenCommands(annotatePaths(filterByTags(commands, tt.tags), nil)) {
+			got = append(got, cmd.FullName())
+		}
+		msg := fmt.Sprintf("tags: %v", tt.tags)
+		Equals(t, msg, tt.wat, got)
+	}
+
+	wat := []tagCount{{"db", 2}, {"prod", 2}, {"staging", 1}, {"web", 1}}
+	Equals(t, "collectTags", wat, collectTags(commands))
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"sort"
+	"strings"
+
+	ui "github.com/fedomn/termui/v3"
+)
+
+// Theme holds the colors of the list by role. Colors are termui color names,
+// "default" is the color of the terminal.
+type Theme struct {
+	Title    string `yaml:"title"`
+	Text     string `yaml:"text"`
+	Border   string `yaml:"border"`
+	Selected string `yaml:"selected"`
+	Group    string `yaml:"group"`
+	Accent   string `yaml:"accent"`
+	Label    string `yaml:"label"`
+	Info     string `yaml:"info"`
+	Success  string `yaml:"success"`
+	Failure  string `yaml:"failure"`
+	Warning  string `yaml:"warning"`
+
+	// Badges are the background colors of tag badges, they are reversed when empty.
+	Badges    []string `yaml:"-"`
+	BadgeText string   `yaml:"-"`
+	// ASCIIBorder draws the borders with -, | and + for terminals without box-drawing characters.
+	ASCIIBorder bool `yaml:"-"`
+}
+
+// ThemeConfig is the theme section of the config: a preset, the border style and colors overriding the preset.
+type ThemeConfig struct {
+	Preset string            `yaml:"preset"`
+	Border string            `yaml:"border"`
+	Colors map[string]string `yaml:"colors"`
+}
+
+var themePresets = map[string]Theme{
+	"dark": {
+		Title: "blue", Text: "cyan", Border: "white", Selected: "green", Group: "yellow", Accent: "red",
+		Label: "magenta", Info: "cyan", Success: "green", Failure: "red", Warning: "yellow",
+		Badges: []string{"cyan", "yellow", "magenta", "green", "blue", "red"}, BadgeText: "black",
+	},
+	"light": {
+		Title: "blue", Text: "black", Border: "black", Selected: "blue", Group: "magenta", Accent: "red",
+		Label: "green", Info: "black", Success: "green", Failure: "red", Warning: "magenta",
+		Badges: []string{"blue", "magenta", "red", "green", "cyan", "black"}, BadgeText: "white",
+	},
+	"monochrome": {
+		Title: "default", Text: "default", Border: "default", Selected: "default", Group: "default", Accent: "default",
+		Label: "default", Info: "default", Success: "default", Failure: "default", Warning: "default",
+	},
+}
+
+// theme is the theme of the list, built from the config by newTheme.
+var theme = themePresets["dark"]
+
+// noColor reports whether colors are turned off by $NO_COLOR, see https://no-color.org, or by a dumb terminal.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb"
+}
+
+// newTheme builds the theme of config over its preset, dark by default. Without colors the monochrome
+// preset is used whatever the config says, and a dumb terminal gets ASCII borders.
+func newTheme(config ThemeConfig) (Theme, error) {
+	preset := config.Preset
+	if preset == "" {
+		preset = "dark"
+	}
+	t, ok := themePresets[preset]
+	if !ok {
+		return t, fmt.Errorf("unknown theme preset %q, expected one of dark, light, monochrome", preset)
+	}
+	switch config.Border {
+	case "", "unicode":
+	case "ascii":
+		t.ASCIIBorder = true
+	default:
+		return t, fmt.Errorf("unknown border %q, expected unicode or ascii", config.Border)
+	}
+
+	roles := make([]string, 0, len(config.Colors))
+	for role := range config.Colors {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		if err := t.setColor(role, config.Colors[role]); err != nil {
+			return t, err
+		}
+	}
+
+	if noColor() {
+		monochrome := themePresets["monochrome"]
+		monochrome.ASCIIBorder = t.ASCIIBorder || os.Getenv("TERM") == "dumb"
+		return monochrome, nil
+	}
+	return t, nil
+}
+
+func (t *Theme) setColor(role string, color string) error {
+	if _, ok := ui.StyleParserColorMap[color]; !ok && color != "default" {
+		return fmt.Errorf("unknown color %q, expected default or one of %s", color, strings.Join(colorNames(), ", "))
+	}
+	fields := map[string]*string{
+		"title": &t.Title, "text": &t.Text, "border": &t.Border, "selected": &t.Selected, "group": &t.Group,
+		"accent": &t.Accent, "label": &t.Label, "info": &t.Info, "success": &t.Success, "failure": &t.Failure,
+		"warning": &t.Warning,
+	}
+	field, ok := fields[role]
+	if !ok {
+		return fmt.Errorf("unknown theme color %q", role)
+	}
+	*field = color
+	return nil
+}
+
+func colorNames() []string {
+	var names []string
+	for name := range ui.StyleParserColorMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// style returns the termui style markup of color with modifiers, like "fg:green,mod:bold".
+func style(color string, modifiers ...string) string {
+	var items []string
+	if color != "" && color != "default" {
+		items = append(items, "fg:"+color)
+	}
+	for _, modifier := range modifiers {
+		items = append(items, "mod:"+modifier)
+	}
+	return strings.Join(items, ",")
+}
+
+// styled wraps text in the termui style markup of color with modifiers.
+func styled(text string, color string, modifiers ...string) string {
+	return fmt.Sprintf("[%s](%s)", text, style(color, modifiers...))
+}
+
+// termColor converts a color name of the theme to a termui color.
+func termColor(color string) ui.Color {
+	if c, ok := ui.StyleParserColorMap[color]; ok {
+		return c
+	}
+	return ui.ColorClear
+}
+
+// asciiBorder draws the border of a widget with ASCII characters, the ones of termui are box-drawing
+// constants. The widget has its own border turned off and draws its title over this one.
+type asciiBorder struct {
+	ui.Drawable
+	block *ui.Block
+}
+
+func (b asciiBorder) Draw(buf *ui.Buffer) {
+	r, borderStyle := b.block.Rectangle, b.block.BorderStyle
+	buf.Fill(ui.NewCell('-', borderStyle), image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1))
+	buf.Fill(ui.NewCell('-', borderStyle), image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y))
+	buf.Fill(ui.NewCell('|', borderStyle), image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y))
+	buf.Fill(ui.NewCell('|', borderStyle), image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y))
+	for _, corner := range []image.Point{r.Min, {r.Max.X - 1, r.Min.Y}, {r.Min.X, r.Max.Y - 1}, r.Max.Sub(image.Pt(1, 1))} {
+		buf.SetCell(ui.NewCell('+', borderStyle), corner)
+	}
+	b.Drawable.Draw(buf)
+}
+
+// styleBlock applies the theme to the title and the border of a widget.
+func styleBlock(block *ui.Block) {
+	block.TitleStyle = ui.NewStyle(termColor(theme.Title), ui.ColorClear, ui.ModifierBold)
+	block.BorderStyle = ui.NewStyle(termColor(theme.Border))
+	block.Border = !theme.ASCIIBorder
+}
+
+// framed returns the widget to render for block, with an ASCII border when the theme asks for it.
+func framed(widget ui.Drawable, block *ui.Block) ui.Drawable {
+	if theme.ASCIIBorder {
+		return asciiBorder{Drawable: widget, block: block}
+	}
+	return widget
+}
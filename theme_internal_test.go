@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestNewTheme(t *testing.T) {
+	defer os.Setenv("TERM", os.Getenv("TERM"))
+	os.Unsetenv("NO_COLOR")
+	os.Setenv("TERM", "xterm-256color")
+
+	var tests = []struct {
+		config ThemeConfig
+		title  string
+		ascii  bool
+		err    string
+	}{
+		{ThemeConfig{}, "blue", false, ""},
+		{ThemeConfig{Preset: "light", Border: "ascii"}, "blue", true, ""},
+		{ThemeConfig{Preset: "monochrome"}, "default", false, ""},
+		{ThemeConfig{Colors: map[string]string{"title": "magenta"}}, "magenta", false, ""},
+		{ThemeConfig{Preset: "solarized"}, "", false, `unknown theme preset "solarized", expected one of dark, light, monochrome`},
+		{ThemeConfig{Border: "double"}, "", false, `unknown border "double", expected unicode or ascii`},
+		{ThemeConfig{Colors: map[string]string{"shadow": "red"}}, "", false, `unknown theme color "shadow"`},
+	}
+	for _, tt := range tests {
+		got, err := newTheme(tt.config)
+		msg := fmt.Sprintf("config: %+v", tt.config)
+		if tt.err != "" {
+			Equals(t, msg, tt.err, fmt.Sprint(err))
+			continue
+		}
+		Equals(t, msg, nil, err)
+		Equals(t, msg, tt.title, got.Title)
+		Equals(t, msg, tt.ascii, got.ASCIIBorder)
+	}
+}
+
+func TestNewThemeWithoutColors(t *testing.T) {
+	defer os.Setenv("TERM", os.Getenv("TERM"))
+	defer os.Unsetenv("NO_COLOR")
+
+	os.Setenv("NO_COLOR", "1")
+	got, err := newTheme(ThemeConfig{Preset: "light", Colors: map[string]string{"title": "red"}})
+	Equals(t, "NO_COLOR error", nil, err)
+	Equals(t, "NO_COLOR title", "default", got.Title)
+	Equals(t, "NO_COLOR border", false, got.ASCIIBorder)
+
+	os.Unsetenv("NO_COLOR")
+	os.Setenv("TERM", "dumb")
+	got, _ = newTheme(ThemeConfig{})
+	Equals(t, "dumb terminal title", "default", got.Title)
+	Equals(t, "dumb terminal border", true, got.ASCIIBorder)
+}
+
+func TestStyled(t *testing.T) {
+	var tests = []struct {
+		color     string
+		modifiers []string
+		wat       string
+	}{
+		{"green", nil, "[x](fg:green)"},
+		{"red", []string{"bold"}, "[x](fg:red,mod:bold)"},
+		{"default", []string{"reverse"}, "[x](mod:reverse)"},
+	}
+	for _, tt := range tests {
+		msg := fmt.Sprintf("color: %s, modifiers: %v", tt.color, tt.modifiers)
+		Equals(t, msg, tt.wat, styled("x", tt.color, tt.modifiers...))
+	}
+}
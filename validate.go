@@ -0,0 +1,409 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+type severity int
+
+const (
+	severityError severity = iota
+	severityWarning
+)
+
+// Diagnostic is a problem found in a config file, Line and Column are 0 when unknown.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Message  string
+	Severity severity
+}
+
+func (d Diagnostic) String() string {
+	position := d.File
+	if d.Line > 0 {
+		position += fmt.Sprintf(":%d", d.Line)
+	}
+	if d.Column > 0 {
+		position += fmt.Sprintf(":%d", d.Column)
+	}
+	level := "error"
+	if d.Severity == severityWarning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", position, level, d.Message)
+}
+
+// hasErrors reports whether diagnostics contain more than warnings.
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+func printDiagnostics(diagnostics []Diagnostic) {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == severityWarning {
+			color.Yellow("%s", diagnostic)
+		} else {
+			color.Red("%s", diagnostic)
+		}
+	}
+}
+
+// validateFiles validates every file and reports whether they are all free of diagnostics.
+// An alias is reported when an earlier file already defines it, as the command of the earlier file wins.
+func validateFiles(files []string) bool {
+	valid := true
+	aliases := make(map[string]aliasDefinition)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Clean(file))
+		if err != nil {
+			color.Red("%s: error: %v", file, err)
+			valid = false
+			continue
+		}
+		if diagnostics := validateConfigAliases(file, data, aliases); len(diagnostics) > 0 {
+			printDiagnostics(diagnostics)
+			valid = false
+		}
+	}
+	return valid
+}
+
+// yamlErrorLine extracts the line number from yaml error messages like "yaml: line 3: ...".
+var yamlErrorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// bwLimitPattern is the rsync --bwlimit rate, KiB/s or a number with a unit suffix.
+var bwLimitPattern = regexp.MustCompile(`^\d+(\.\d+)?[bBkKmMgGtTpP]?$`)
+
+// configValidator walks the yaml nodes of one config file, so that every problem is reported with its position.
+type configValidator struct {
+	file        string
+	diagnostics []Diagnostic
+	aliases     map[string]aliasDefinition
+}
+
+// aliasDefinition is where an alias is first defined, aliases are unique over all the files validated together.
+type aliasDefinition struct {
+	file string
+	line int
+}
+
+// validateConfig checks data of the config file named file: yaml syntax, unknown keys, empty name or cmd,
+// duplicate names in a group, duplicate aliases, aliases hidden by a subcommand and missing ssh key files.
+func validateConfig(file string, data []byte) []Diagnostic {
+	return validateConfigAliases(file, data, make(map[string]aliasDefinition))
+}
+
+// validateConfigAliases is validateConfig with the aliases defined by the files validated before, it adds the
+// aliases of file to them.
+func validateConfigAliases(file string, data []byte, aliases map[string]aliasDefinition) []Diagnostic {
+	v := &configValidator{file: file, aliases: aliases}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		v.addYAMLError(err)
+		return v.diagnostics
+	}
+	if len(root.Content) == 0 {
+		v.diagnostics = append(v.diagnostics, Diagnostic{File: file, Message: "config is empty"})
+		return v.diagnostics
+	}
+
+	doc := root.Content[0]
+	switch doc.Kind {
+	case yaml.SequenceNode:
+		v.validateCommands(doc)
+	case yaml.MappingNode:
+		v.validateKeys(doc, reflect.TypeOf(Config{}))
+		var config Config
+		v.decode(doc, &config)
+		if commands := mappingValue(doc, "commands"); commands != nil {
+			v.validateCommands(commands)
+		}
+		if keymap := mappingValue(doc, "keymap"); keymap != nil {
+			v.validateKeymap(keymap)
+		}
+		if theme := mappingValue(doc, "theme"); theme != nil && theme.Kind == yaml.MappingNode {
+			v.validateKeys(theme, reflect.TypeOf(ThemeConfig{}))
+			v.validateTheme(theme)
+		}
+	default:
+		v.add(doc, severityError, "expected a list of commands or a mapping with commands")
+	}
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		return v.diagnostics[i].Line < v.diagnostics[j].Line
+	})
+	return v.diagnostics
+}
+
+func (v *configValidator) add(node *yaml.Node, level severity, format string, a ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		File:     v.file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Message:  fmt.Sprintf(format, a...),
+		Severity: level,
+	})
+}
+
+func (v *configValidator) validateAlias(item *yaml.Node, alias string) {
+	if isSubcommand(alias) {
+		v.add(item, severityError, "alias %q is hidden by the c %s subcommand", alias, alias)
+		return
+	}
+	first, ok := v.aliases[alias]
+	switch {
+	case !ok:
+		v.aliases[alias] = aliasDefinition{file: v.file, line: item.Line}
+	case first.file == v.file:
+		v.add(item, severityError, "duplicate alias %q, first defined at line %d", alias, first.line)
+	default:
+		v.add(item, severityError, "duplicate alias %q, first defined at %s:%d", alias, first.file, first.line)
+	}
+}
+
+func (v *configValidator) addYAMLError(err error) {
+	messages := []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+	for _, message := range messages {
+		diagnostic := Diagnostic{File: v.file, Message: message}
+		if match := yamlErrorLine.FindStringSubmatch(message); match != nil {
+			diagnostic.Line, _ = strconv.Atoi(match[1])
+			diagnostic.Message = match[2]
+		}
+		// decoding the whole config and then each command reports the same type errors twice
+		if !v.reported(diagnostic) {
+			v.diagnostics = append(v.diagnostics, diagnostic)
+		}
+	}
+}
+
+func (v *configValidator) reported(diagnostic Diagnostic) bool {
+	for _, d := range v.diagnostics {
+		if d == diagnostic {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *configValidator) decode(node *yaml.Node, out interface{}) bool {
+	if err := node.Decode(out); err != nil {
+		v.addYAMLError(err)
+		return false
+	}
+	return true
+}
+
+// validateKeys reports the keys of mapping which are not fields of the struct type t.
+func (v *configValidator) validateKeys(mapping *yaml.Node, t reflect.Type) {
+	known := yamlKeys(t)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if !known[key.Value] {
+			v.add(key, severityError, "unknown key %q", key.Value)
+		}
+	}
+}
+
+func (v *configValidator) validateCommands(sequence *yaml.Node) {
+	if sequence.Kind != yaml.SequenceNode {
+		v.add(sequence, severityError, "expected a list of commands")
+		return
+	}
+
+	names := make(map[string]*yaml.Node)
+	for _, item := range sequence.Content {
+		if item.Kind != yaml.MappingNode {
+			v.add(item, severityError, "expected a command with name and cmd")
+			continue
+		}
+		v.validateKeys(item, reflect.TypeOf(Cmd{}))
+		if rsync := mappingValue(item, "rsync"); rsync != nil && rsync.Kind == yaml.MappingNode {
+			v.validateKeys(rsync, reflect.TypeOf(RsyncOptions{}))
+		}
+
+		var cmd Cmd
+		if !v.decode(item, &cmd) {
+			continue
+		}
+
+		if strings.TrimSpace(cmd.Name) == "" {
+			v.add(item, severityError, "command has an empty name")
+		} else if first, ok := names[cmd.Name]; ok {
+			v.add(item, severityError, "duplicate name %q, first defined at line %d", cmd.Name, first.Line)
+		} else {
+			names[cmd.Name] = item
+		}
+
+		if cmd.Alias != "" {
+			v.validateAlias(item, cmd.Alias)
+		}
+
+		if children := mappingValue(item, "children"); children != nil {
+			v.validateCommands(children)
+			continue
+		}
+		if steps := mappingValue(item, "steps"); steps != nil {
+			if cmd.Cmd != "" {
+				v.add(item, severityError, "command %q has both cmd and steps", cmd.Name)
+			}
+			v.validateSteps(steps, cmd.Name)
+			continue
+		}
+		if strings.TrimSpace(cmd.Cmd) == "" {
+			v.add(item, severityError, "command %q has an empty cmd", cmd.Name)
+			continue
+		}
+		if rsync := mappingValue(item, "rsync"); rsync != nil {
+			v.validateRsync(rsync, cmd)
+		}
+		if keyFile := sshKeyFile(cmd.Cmd); keyFile != "" {
+			if _, err := os.Stat(keyFile); err != nil {
+				v.add(mappingValue(item, "cmd"), severityWarning, "ssh key file %s of %q does not exist", keyFile, cmd.Name)
+			}
+		}
+	}
+}
+
+// validateRsync reports the flags and the bandwidth limit of an rsync block that rsync would reject.
+func (v *configValidator) validateRsync(rsync *yaml.Node, cmd Cmd) {
+	if flags := mappingValue(rsync, "flags"); flags != nil {
+		for _, flag := range strings.Fields(cmd.Rsync.Flags) {
+			if !strings.HasPrefix(flag, "-") {
+				v.add(flags, severityError, "rsync flag %q of %q is not an option", flag, cmd.Name)
+			}
+		}
+	}
+	if bwLimit := mappingValue(rsync, "bwlimit"); bwLimit != nil && !bwLimitPattern.MatchString(cmd.Rsync.BwLimit) {
+		v.add(bwLimit, severityError, "rsync bwlimit %q of %q is not a rate like 500 or 1.5m", cmd.Rsync.BwLimit, cmd.Name)
+	}
+}
+
+// validateKeymap reports the bindings rejected by checkBinding, type errors are left to decode.
+func (v *configValidator) validateKeymap(keymap *yaml.Node) {
+	if keymap.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(keymap.Content); i += 2 {
+		mode, bindings := keymap.Content[i], keymap.Content[i+1]
+		if _, ok := keymapModes[mode.Value]; !ok {
+			v.add(mode, severityError, "%v", checkBinding(mode.Value, "", ""))
+			continue
+		}
+		if bindings.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(bindings.Content); j += 2 {
+			key, action := bindings.Content[j], bindings.Content[j+1]
+			if err := checkBinding(mode.Value, key.Value, action.Value); err != nil {
+				v.add(key, severityError, "%v", err)
+			}
+		}
+	}
+}
+
+// validateTheme reports the preset, border and colors rejected by newTheme.
+func (v *configValidator) validateTheme(theme *yaml.Node) {
+	if preset := mappingValue(theme, "preset"); preset != nil {
+		if _, err := newTheme(ThemeConfig{Preset: preset.Value}); err != nil {
+			v.add(preset, severityError, "%v", err)
+		}
+	}
+	if border := mappingValue(theme, "border"); border != nil {
+		if _, err := newTheme(ThemeConfig{Border: border.Value}); err != nil {
+			v.add(border, severityError, "%v", err)
+		}
+	}
+	colors := mappingValue(theme, "colors")
+	if colors == nil || colors.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(colors.Content); i += 2 {
+		role, color := colors.Content[i], colors.Content[i+1]
+		if _, err := newTheme(ThemeConfig{Colors: map[string]string{role.Value: color.Value}}); err != nil {
+			v.add(role, severityError, "%v", err)
+		}
+	}
+}
+
+func (v *configValidator) validateSteps(sequence *yaml.Node, name string) {
+	if sequence.Kind != yaml.SequenceNode {
+		v.add(sequence, severityError, "expected a list of steps")
+		return
+	}
+	for i, item := range sequence.Content {
+		if item.Kind != yaml.MappingNode {
+			v.add(item, severityError, "expected a step with cmd")
+			continue
+		}
+		v.validateKeys(item, reflect.TypeOf(Step{}))
+		var step Step
+		if v.decode(item, &step) && strings.TrimSpace(step.Cmd) == "" {
+			v.add(item, severityError, "%s of %q has an empty cmd", step.displayName(i), name)
+		}
+	}
+}
+
+// mappingValue returns the value node of key in mapping, nil when it is missing.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlKeys returns the yaml keys of the fields of struct type t.
+func yamlKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			keys[tag] = true
+		}
+	}
+	return keys
+}
+
+// sshKeyFile returns the path given to -i of an ssh command, empty when there is none
+// or when it contains a placeholder.
+func sshKeyFile(cmdStr string) string {
+	fields, err := shellFields(cmdStr)
+	if err != nil {
+		fields = strings.Fields(cmdStr)
+	}
+	for i, field := range fields {
+		if filepath.Base(field) != "ssh" {
+			continue
+		}
+		target, err := parseSSHArgs(fields[i+1:])
+		if err != nil || target.identityFile == "" || placeholderPattern.MatchString(target.identityFile) {
+			continue
+		}
+		return target.identityFile
+	}
+	return ""
+}
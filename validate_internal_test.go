@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	var tests = []struct {
+		data string
+		wat  []string
+	}{
+		{"- name: date\n  cmd: date", nil},
+		{"", []string{"c.yaml: error: config is empty"}},
+		{"- name: date\n  cmd: [date", []string{"c.yaml:1: error: did not find expected ',' or ']'"}},
+		{"- name: date\n  cmd: date\n  colour: red", []string{`c.yaml:3:3: error: unknown key "colour"`}},
+		{"- name: date\n  cmd: ''", []string{`c.yaml:1:3: error: command "date" has an empty cmd`}},
+		{"- cmd: date", []string{`c.yaml:1:3: error: command has an empty name`}},
+		{"return: yes please\ncommands:\n- name: date\n  cmd: date", []string{"c.yaml:1: error: cannot unmarshal !!str `yes please` into bool"}},
+		{
+			"- name: a\n  alias: x\n  cmd: date\n- name: g\n  children:\n  - name: a\n    alias: x\n    cmd: date\n- name: a\n  cmd: date",
+			[]string{
+				`c.yaml:6:5: error: duplicate alias "x", first defined at line 1`,
+				`c.yaml:9:3: error: duplicate name "a", first defined at line 1`,
+			},
+		},
+		{"- name: ls\n  alias: list\n  cmd: ls\n- name: check\n  alias: validate\n  cmd: make check", []string{
+			`c.yaml:1:3: error: alias "list" is hidden by the c list subcommand`,
+			`c.yaml:4:3: error: alias "validate" is hidden by the c validate subcommand`,
+		}},
+		{"- name: ci\n  steps:\n  - name: test\n    cmd: go test\n  - cmd: ''", []string{`c.yaml:5:5: error: step 2 of "ci" has an empty cmd`}},
+		{"- name: ci\n  cmd: make\n  steps:\n  - cmd: go test\n    retry: 2", []string{
+			`c.yaml:1:3: error: command "ci" has both cmd and steps`,
+			`c.yaml:5:5: error: unknown key "retry"`,
+		}},
+		{"keymap:\n  normal:\n    <C-n>: scroll-down\n  search:\n    x: back\ncommands:\n- name: date\n  cmd: date",
+			[]string{`c.yaml:5:5: error: key "x" conflicts with typing the search string`}},
+		{"keymap:\n  normal:\n    j: quit\n    j: search\ncommands:\n- name: date\n  cmd: date",
+			[]string{`c.yaml:4: error: mapping key "j" already defined at line 3`}},
+		{"theme:\n  preset: solarized\n  border: ascii\n  colors:\n    title: teal\n    shadow: red\ncommands:\n- name: date\n  cmd: date",
+			[]string{
+				`c.yaml:2:11: error: unknown theme preset "solarized", expected one of dark, light, monochrome`,
+				`c.yaml:5:5: error: unknown color "teal", expected default or one of ` + strings.Join(colorNames(), ", "),
+				`c.yaml:6:5: error: unknown theme color "shadow"`,
+			}},
+		{
+			"- name: srv\n  cmd: ssh -p 22 -i /nonexistent/key user@ip",
+			[]string{`c.yaml:2:8: warning: ssh key file /nonexistent/key of "srv" does not exist`},
+		},
+		{
+			"- name: srv\n  cmd: ssh root@ip\n  rsync:\n    dest: /srv\n    flags: -az partial\n    bwlimit: fast\n    compress: true",
+			[]string{
+				`c.yaml:5:12: error: rsync flag "partial" of "srv" is not an option`,
+				`c.yaml:6:14: error: rsync bwlimit "fast" of "srv" is not a rate like 500 or 1.5m`,
+				`c.yaml:7:5: error: unknown key "compress"`,
+			},
+		},
+		{
+			"- name: srv\n  cmd: cd /tmp && ssh -Cp22 -o IdentityFile=/nonexistent/other ip",
+			[]string{`c.yaml:2:8: warning: ssh key file /nonexistent/other of "srv" does not exist`},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, diagnostic := range validateConfig("c.yaml", []byte(tt.data)) {
+			got = append(got, diagnostic.String())
+		}
+		msg := fmt.Sprintf("data: %s", tt.data)
+		Equals(t, msg, tt.wat, got)
+	}
+}
+
+func TestValidateConfigAliases(t *testing.T) {
+	aliases := make(map[string]aliasDefinition)
+	var tests = []struct {
+		file string
+		data string
+		wat  []string
+	}{
+		{"/src/app/.c.yaml", "- name: deploy\n  alias: d\n  cmd: make deploy", nil},
+		{"/etc/c/config.yaml", "- name: date\n  cmd: date\n- name: deploy\n  alias: d\n  cmd: ./deploy.sh",
+			[]string{`/etc/c/config.yaml:3:3: error: duplicate alias "d", first defined at /src/app/.c.yaml:1`}},
+		{"/etc/c/other.yaml", "- name: date\n  alias: dt\n  cmd: date\n- name: now\n  alias: dt\n  cmd: date",
+			[]string{`/etc/c/other.yaml:4:3: error: duplicate alias "dt", first defined at line 1`}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, diagnostic := range validateConfigAliases(tt.file, []byte(tt.data), aliases) {
+			got = append(got, diagnostic.String())
+		}
+		Equals(t, "file: "+tt.file, tt.wat, got)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Each widget runs "c --print" and inserts the selected command at the cursor, bound to Ctrl-G.
+// The TUI draws on /dev/tty, so it works while the widget captures stdout.
+var shellWidgets = map[string]string{
+	"bash": `__c_widget() {
+  local selected
+  selected="$(%[1]s --print)" || return
+  READLINE_LINE="${READLINE_LINE:0:$READLINE_POINT}${selected}${READLINE_LINE:$READLINE_POINT}"
+  READLINE_POINT=$((READLINE_POINT + ${#selected}))
+}
+bind -m emacs-standard -x '"\C-g": __c_widget'
+bind -m vi-command -x '"\C-g": __c_widget'
+bind -m vi-insert -x '"\C-g": __c_widget'
+`,
+	"zsh": `__c_widget() {
+  local selected
+  selected="$(%[1]s --print)"
+  local ret=$?
+  if [[ -n "$selected" ]]; then
+    LBUFFER="${LBUFFER}${selected}"
+  fi
+  zle reset-prompt
+  return $ret
+}
+zle -N __c_widget
+bindkey '^G' __c_widget
+`,
+	"fish": `function __c_widget
+  set -l selected (%[1]s --print)
+  if test -n "$selected"
+    commandline -i -- "$selected"
+  end
+  commandline -f repaint
+end
+bind \cg __c_widget
+if bind -M insert > /dev/null 2>&1
+  bind -M insert \cg __c_widget
+end
+`,
+}
+
+// shellWidget returns the widget script of shell, calling c by the path of the running binary.
+func shellWidget(shell string) (string, error) {
+	script, ok := shellWidgets[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", shell)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "c"
+	}
+	return fmt.Sprintf(script, shellQuote(executable)), nil
+}